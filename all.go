@@ -0,0 +1,184 @@
+package goreadme
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/golang/gddo/doc"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// WriteAll writes a README file to the directory of each package in the module under dir, with
+// the same configuration, or with the config file of each package if the converter was created
+// with WithConfigFiles. fileName is the README file path relative to each package directory.
+// If APIFile is configured, the API reference file is also written next to each README file.
+// All the packages are loaded once, and the README files are generated concurrently. It returns
+// the paths of the README files that were changed.
+//
+// If ImportPath is configured, it is used as the import path of dir, and the import paths of the
+// packages are relative to it.
+func (r *GoReadme) WriteAll(ctx context.Context, dir, fileName string) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := loadLocalPackages(ctx, absDir, "./...")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed loading %s", dir)
+	}
+	src := loadedSource(pkgs)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    *multierror.Error
+		changed []string
+	)
+	for pkgDir, p := range pkgs {
+		if p.Name == "" {
+			continue
+		}
+		rel, err := filepath.Rel(absDir, pkgDir)
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, rel, fileName)
+
+		cfg, err := r.packageConfig(pkgDir)
+		if err != nil {
+			return nil, err
+		}
+		// An import path that is not set in the config file of the package is of dir.
+		if cfg.ImportPath != "" && cfg.ImportPath == r.config.ImportPath && rel != "." {
+			cfg.ImportPath += "/" + filepath.ToSlash(rel)
+		}
+		paths := []string{path}
+		if cfg.APIFile != "" {
+			apiPath := filepath.Join(filepath.Dir(path), cfg.APIFile)
+			paths = append(paths, apiPath)
+			if cfg.APIReadmeLink == "" {
+				cfg.APIReadmeLink, err = relativeLink(apiPath, path)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		for i, path := range paths {
+			api := i > 0
+			wg.Add(1)
+			// Each goroutine has its own copy of the converter.
+			g := r.WithSource(src).WithConfig(cfg)
+			go func(name string) {
+				defer wg.Done()
+				ok, err := g.write(ctx, name, path, api)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = multierror.Append(errs, errors.Wrapf(err, "failed writing %s", path))
+					return
+				}
+				if ok {
+					changed = append(changed, path)
+				}
+			}(pkgDir)
+		}
+	}
+	wg.Wait()
+	sort.Strings(changed)
+	return changed, errs.ErrorOrNil()
+}
+
+// packageConfig returns the configuration of the package in dir: the config file closest to dir
+// over the configuration of the converter, if it was created with WithConfigFiles.
+func (r *GoReadme) packageConfig(dir string) (Config, error) {
+	cfg := r.config
+	if !r.configFiles {
+		return cfg, nil
+	}
+	file, err := FindConfig(dir)
+	if err != nil {
+		return cfg, errors.Wrapf(err, "failed looking for config file of %s", dir)
+	}
+	if file != "" {
+		if err := LoadConfig(file, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if r.override != nil {
+		r.override(&cfg)
+	}
+	return cfg, nil
+}
+
+// write writes the README, or the API reference file if api is set, of the package to the given
+// path, and returns whether the file was changed. If Markers is set and the README exists, only
+// the marked content is updated.
+func (r *GoReadme) write(ctx context.Context, name, path string, api bool) (bool, error) {
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	var content []byte
+	if api {
+		buf := bytes.NewBuffer(nil)
+		err = r.CreateAPI(ctx, name, buf)
+		content = buf.Bytes()
+	} else {
+		content, err = r.render(ctx, name, old)
+	}
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(old, content) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, content, 0664)
+}
+
+// loadedSource is a source of packages that were already loaded, mapped by their absolute
+// directory. Directories that are not loaded packages only have sub directories.
+type loadedSource map[string]*doc.Package
+
+func (s loadedSource) Package(_ context.Context, name string) (*doc.Package, error) {
+	if p, ok := s[filepath.Clean(name)]; ok {
+		return p, nil
+	}
+	subdirs, err := localSubdirs(name)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Package{Subdirectories: subdirs}, nil
+}
+
+func (s loadedSource) Dir(_ context.Context, name string) (string, error) {
+	return name, nil
+}
+
+func (s loadedSource) Subdirectories(_ context.Context, name string) ([]string, error) {
+	if p, ok := s[filepath.Clean(name)]; ok {
+		return p.Subdirectories, nil
+	}
+	return localSubdirs(name)
+}
+
+// relativeLink returns the link from the file in the from path to the file in the to path.
+func relativeLink(from, to string) (string, error) {
+	from, err := filepath.Abs(from)
+	if err != nil {
+		return "", err
+	}
+	to, err = filepath.Abs(to)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(filepath.Dir(from), to)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
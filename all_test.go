@@ -0,0 +1,105 @@
+package goreadme
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAll(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	copyDir(t, "./testdata/pkg2_recursive", dir)
+	require.NoError(t, os.Remove(filepath.Join(dir, "README.md")))
+
+	ctx := context.Background()
+	g := gr.WithConfig(Config{ImportPath: "example.com/pkg2"})
+
+	// The itest directory has only test files, and does not get a README.
+	changed, err := g.WriteAll(ctx, dir, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "README.md"),
+		filepath.Join(dir, "subpkg1", "README.md"),
+		filepath.Join(dir, "subpkg1", "subsubpkg", "README.md"),
+		filepath.Join(dir, "subpkg2", "README.md"),
+	}, changed)
+
+	sub, err := os.ReadFile(filepath.Join(dir, "subpkg1", "README.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(sub), "# subpkg1")
+
+	// A second run does not change any file.
+	changed, err = g.WriteAll(ctx, dir, "README.md")
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+}
+
+func TestWriteAllAPIFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	copyDir(t, "./testdata/pkg2_recursive", dir)
+
+	g := gr.WithConfig(Config{ImportPath: "example.com/pkg2", APIFile: "API.md"})
+	_, err := g.WriteAll(context.Background(), dir, "DOC.md")
+	require.NoError(t, err)
+
+	// The API reference files link to the README files of their packages.
+	api, err := os.ReadFile(filepath.Join(dir, "subpkg1", "API.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(api), "See the [README](DOC.md) for an overview.")
+}
+
+func TestWriteAllConfigFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	copyDir(t, "./testdata/pkg2_recursive", dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "subpkg1", ".goreadme.json"), []byte(`{"title": "Sub"}`), 0664))
+
+	g := gr.WithConfig(Config{ImportPath: "example.com/pkg2", Credit: true}).
+		WithConfigFiles(func(cfg *Config) { cfg.Credit = false })
+	_, err := g.WriteAll(context.Background(), dir, "README.md")
+	require.NoError(t, err)
+
+	// The config file of a package applies to it and to its sub packages.
+	for _, rel := range []string{"subpkg1", filepath.Join("subpkg1", "subsubpkg")} {
+		readme, err := os.ReadFile(filepath.Join(dir, rel, "README.md"))
+		require.NoError(t, err)
+		assert.Contains(t, string(readme), "# Sub\n", rel)
+		assert.NotContains(t, string(readme), "goreadme", rel)
+	}
+	readme, err := os.ReadFile(filepath.Join(dir, "subpkg2", "README.md"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(readme), "# Sub\n")
+	// The override applies to all the packages.
+	assert.NotContains(t, string(readme), "goreadme")
+}
+
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0775)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), b, 0664)
+	})
+	require.NoError(t, err)
+}
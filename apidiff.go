@@ -0,0 +1,486 @@
+package goreadme
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/gddo/doc"
+	"github.com/pkg/errors"
+	"github.com/posener/goreadme/internal/template"
+)
+
+// Kinds of API changes.
+const (
+	APIAdded   = "added"
+	APIRemoved = "removed"
+	APIChanged = "changed"
+)
+
+// APIChange is a change in the exported API of a package between two versions.
+type APIChange struct {
+	// Kind of the identifier: const, var, func, type, method or field. Interface methods are
+	// methods, and embedded types are fields.
+	Kind string
+	// Name of the identifier. Methods and fields are qualified with their type, such as
+	// `Type.Method`.
+	Name string
+	// Change is one of APIAdded, APIRemoved or APIChanged.
+	Change string
+	// Old and New are the declarations of the identifier in each version. Old is empty for added
+	// identifiers, and New is empty for removed ones.
+	Old, New string
+	// Incompatible is true if code that uses the old version might not compile with the new one.
+	Incompatible bool
+}
+
+// APIDiffReport is the changes in the exported API of a package between two refs.
+type APIDiffReport struct {
+	// Package is the package at the new ref.
+	Package        *doc.Package
+	OldRef, NewRef string
+	// Changes are sorted by name.
+	Changes []APIChange
+}
+
+// APIDiff writes to w a markdown report of the changes in the exported API of the package between
+// two refs, with the incompatible changes first.
+func (r *GoReadme) APIDiff(ctx context.Context, name, oldRef, newRef string, w io.Writer) error {
+	report, err := r.APIChanges(ctx, name, oldRef, newRef)
+	if err != nil {
+		return err
+	}
+	return r.WriteAPIDiff(w, report)
+}
+
+// APIChanges returns the changes in the exported API of the package between two refs. Both
+// versions are loaded like Create loads the package: a local package is checked out at each ref
+// in a temporary git worktree, and a remote package is loaded from the Go module proxy at each
+// ref, as with Config.Ref. Packages of sources that are set with WithSource can't be loaded at a
+// ref, and an error is returned for them.
+func (r *GoReadme) APIChanges(ctx context.Context, name, oldRef, newRef string) (*APIDiffReport, error) {
+	p, changes, err := r.apiChanges(ctx, name, oldRef, newRef)
+	if err != nil {
+		return nil, err
+	}
+	return &APIDiffReport{Package: p, OldRef: oldRef, NewRef: newRef, Changes: changes}, nil
+}
+
+// WriteAPIDiff writes to w the markdown report of API changes that were returned by APIChanges,
+// like APIDiff does.
+func (r *GoReadme) WriteAPIDiff(w io.Writer, report *APIDiffReport) error {
+	data := apiDiff{Package: report.Package, OldRef: report.OldRef, NewRef: report.NewRef}
+	for _, c := range report.Changes {
+		if c.Incompatible {
+			data.Incompatible = append(data.Incompatible, c)
+		} else {
+			data.Compatible = append(data.Compatible, c)
+		}
+	}
+	return template.Execute(w, template.APIDiff, data, r.config, nil)
+}
+
+// apiDiff is the data of the API diff template.
+type apiDiff struct {
+	Package                  *doc.Package
+	OldRef, NewRef           string
+	Incompatible, Compatible []APIChange
+}
+
+func (r *GoReadme) apiChanges(ctx context.Context, name, oldRef, newRef string) (*doc.Package, []APIChange, error) {
+	oldPkg, err := r.getRef(ctx, name, oldRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	newPkg, err := r.getRef(ctx, name, newRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newPkg, diffAPI(apiDecls(oldPkg), apiDecls(newPkg)), nil
+}
+
+// getRef loads the package at the given ref.
+func (r *GoReadme) getRef(ctx context.Context, name, ref string) (*doc.Package, error) {
+	cfg := r.config
+	cfg.SkipSubPackages, cfg.Flags = true, false
+	// Deprecated identifiers are still part of the API.
+	cfg.Deprecated = ""
+	if r.source != nil {
+		// Sources return the same package regardless of Config.Ref.
+		return nil, errors.Errorf("can't load %s at %s from a custom source", name, ref)
+	}
+	if !isLocal(name) {
+		cfg.Ref = ref
+		p, err := r.WithConfig(cfg).get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return p.Package, nil
+	}
+	dir, cleanup, err := worktree(ctx, name, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	p, err := r.WithConfig(cfg).get(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	return p.Package, nil
+}
+
+// worktree checks out the ref of the git repository of the package in dir to a temporary git
+// worktree. It returns the package directory in the worktree and a function that removes it.
+func worktree(ctx context.Context, dir, ref string) (string, func(), error) {
+	top, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed finding git repository of %s", dir)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	// Git returns the repository path with symbolic links resolved.
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return "", nil, err
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.MkdirTemp("", "goreadme")
+	if err != nil {
+		return "", nil, err
+	}
+	wt := filepath.Join(tmp, "worktree")
+	if _, err := git(ctx, top, "worktree", "add", "--detach", wt, ref); err != nil {
+		os.RemoveAll(tmp)
+		return "", nil, errors.Wrapf(err, "failed checking out %s", ref)
+	}
+	cleanup := func() {
+		git(context.Background(), top, "worktree", "remove", "--force", wt)
+		os.RemoveAll(tmp)
+	}
+	return filepath.Join(wt, rel), cleanup, nil
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// apiDecl is an exported declaration of a package.
+type apiDecl struct {
+	kind, name string
+	// decl is the declaration, formatted on a single line.
+	decl string
+	// sig is the declaration without parameter names, which is compared between versions.
+	sig string
+	// addIncompatible is true if adding the declaration breaks existing code, as adding a method
+	// to an interface does.
+	addIncompatible bool
+}
+
+func (d apiDecl) key() string {
+	return d.kind + " " + d.name
+}
+
+// parent returns the type name of a method or a field, or an empty string for other kinds.
+func (d apiDecl) parent() string {
+	if i := strings.Index(d.name, "."); i >= 0 {
+		return d.name[:i]
+	}
+	return ""
+}
+
+// apiDecls returns the exported declarations of the package by their keys.
+func apiDecls(p *doc.Package) map[string]apiDecl {
+	decls := make(map[string]apiDecl)
+	add := func(d apiDecl) {
+		if d.sig == "" {
+			d.sig = d.decl
+		}
+		decls[d.key()] = d
+	}
+	values := func(vs []*doc.Value) {
+		for _, v := range vs {
+			valueDecls(v.Decl.Text, add)
+		}
+	}
+	funcs := func(fs []*doc.Func) {
+		for _, f := range fs {
+			funcDecl(f.Decl.Text, add)
+		}
+	}
+	values(p.Consts)
+	values(p.Vars)
+	funcs(p.Funcs)
+	for _, t := range p.Types {
+		typeDecls(t.Decl.Text, add)
+		values(t.Consts)
+		values(t.Vars)
+		funcs(t.Funcs)
+		funcs(t.Methods)
+	}
+	return decls
+}
+
+// valueDecls adds the exported consts or vars of a const or var declaration.
+func valueDecls(text string, add func(apiDecl)) {
+	fset, f := parseDecl(text)
+	if f == nil {
+		return
+	}
+	decl, ok := f.Decls[0].(*ast.GenDecl)
+	if !ok {
+		return
+	}
+	kind := decl.Tok.String()
+	var typ ast.Expr
+	var values []ast.Expr
+	for i, spec := range decl.Specs {
+		s, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		// Constants without a type and values repeat the previous ones.
+		if s.Type != nil || len(s.Values) > 0 || decl.Tok != token.CONST {
+			typ, values = s.Type, s.Values
+		}
+		for j, n := range s.Names {
+			if !n.IsExported() {
+				continue
+			}
+			d := kind + " " + n.Name
+			if typ != nil {
+				d += " " + nodeSource(fset, typ)
+			}
+			if decl.Tok == token.CONST && j < len(values) {
+				v := nodeSource(fset, values[j])
+				if strings.Contains(v, "iota") {
+					v += fmt.Sprintf(" (iota = %d)", i)
+				}
+				d += " = " + v
+			}
+			add(apiDecl{kind: kind, name: n.Name, decl: d})
+		}
+	}
+}
+
+// funcDecl adds the declaration of a function or a method.
+func funcDecl(text string, add func(apiDecl)) {
+	fset, f := parseDecl(text)
+	if f == nil {
+		return
+	}
+	decl, ok := f.Decls[0].(*ast.FuncDecl)
+	if !ok {
+		return
+	}
+	decl.Doc, decl.Body = nil, nil
+	d := apiDecl{kind: "func", name: decl.Name.Name, decl: nodeSource(fset, decl)}
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		d.kind = "method"
+		d.name = embeddedName(decl.Recv.List[0].Type) + "." + d.name
+	}
+	dropNames(decl.Recv)
+	dropNames(decl.Type.Params)
+	dropNames(decl.Type.Results)
+	d.sig = nodeSource(fset, decl)
+	add(d)
+}
+
+// typeDecls adds the exported type declarations, with their exported struct fields and interface
+// methods.
+func typeDecls(text string, add func(apiDecl)) {
+	fset, f := parseDecl(text)
+	if f == nil {
+		return
+	}
+	decl, ok := f.Decls[0].(*ast.GenDecl)
+	if !ok {
+		return
+	}
+	for _, spec := range decl.Specs {
+		s, ok := spec.(*ast.TypeSpec)
+		if !ok || !s.Name.IsExported() {
+			continue
+		}
+		name := s.Name.Name
+		head := "type " + name
+		if s.TypeParams != nil {
+			head += typeParams(fset, s.TypeParams)
+		}
+		if s.Assign.IsValid() {
+			head += " ="
+		}
+		switch t := s.Type.(type) {
+		case *ast.StructType:
+			add(apiDecl{kind: "type", name: name, decl: head + " struct"})
+			for _, field := range t.Fields.List {
+				typ := nodeSource(fset, field.Type)
+				if len(field.Names) == 0 {
+					if n := embeddedName(field.Type); ast.IsExported(n) {
+						add(apiDecl{kind: "field", name: name + "." + n, decl: typ})
+					}
+					continue
+				}
+				for _, n := range field.Names {
+					if n.IsExported() {
+						add(apiDecl{kind: "field", name: name + "." + n.Name, decl: n.Name + " " + typ})
+					}
+				}
+			}
+		case *ast.InterfaceType:
+			add(apiDecl{kind: "type", name: name, decl: head + " interface"})
+			for _, field := range t.Methods.List {
+				if len(field.Names) == 0 {
+					// Embedded interfaces and type constraints.
+					elem := nodeSource(fset, field.Type)
+					add(apiDecl{kind: "field", name: name + "." + elem, decl: elem, addIncompatible: true})
+					continue
+				}
+				for _, n := range field.Names {
+					if !n.IsExported() {
+						continue
+					}
+					d := apiDecl{kind: "method", name: name + "." + n.Name, addIncompatible: true}
+					d.decl = n.Name + strings.TrimPrefix(nodeSource(fset, field.Type), "func")
+					if ft, ok := field.Type.(*ast.FuncType); ok {
+						dropNames(ft.Params)
+						dropNames(ft.Results)
+					}
+					d.sig = n.Name + strings.TrimPrefix(nodeSource(fset, field.Type), "func")
+					add(d)
+				}
+			}
+		default:
+			add(apiDecl{kind: "type", name: name, decl: head + " " + nodeSource(fset, s.Type)})
+		}
+	}
+}
+
+// parseDecl parses the text of a single declaration.
+func parseDecl(text string) (*token.FileSet, *ast.File) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n"+text, 0)
+	if err != nil || len(f.Decls) == 0 {
+		return nil, nil
+	}
+	return fset, f
+}
+
+// nodeSource returns the source of the node, without comments, on a single line.
+func nodeSource(fset *token.FileSet, n ast.Node) string {
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, fset, n); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// dropNames removes the names of parameters, which do not affect compatibility.
+func dropNames(fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	var list []*ast.Field
+	for _, f := range fields.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			list = append(list, &ast.Field{Type: f.Type})
+		}
+	}
+	fields.List = list
+}
+
+func typeParams(fset *token.FileSet, params *ast.FieldList) string {
+	var ps []string
+	for _, p := range params.List {
+		var names []string
+		for _, n := range p.Names {
+			names = append(names, n.Name)
+		}
+		ps = append(ps, strings.Join(names, ", ")+" "+nodeSource(fset, p.Type))
+	}
+	return "[" + strings.Join(ps, ", ") + "]"
+}
+
+// embeddedName returns the name of a type expression, which is the field name of an embedded
+// type and the type name of a method receiver.
+func embeddedName(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// diffAPI returns the changes between the old and the new declarations, sorted by name. Fields
+// and methods of added and removed types are not listed separately.
+func diffAPI(before, after map[string]apiDecl) []APIChange {
+	type change struct {
+		APIChange
+		parent string
+	}
+	var changes []change
+	types := make(map[string]bool)
+	for key, d := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			changes = append(changes, change{APIChange{Kind: d.kind, Name: d.name, Change: APIAdded, New: d.decl, Incompatible: d.addIncompatible}, d.parent()})
+			if d.kind == "type" {
+				types[d.name] = true
+			}
+		case old.sig != d.sig:
+			changes = append(changes, change{APIChange{Kind: d.kind, Name: d.name, Change: APIChanged, Old: old.decl, New: d.decl, Incompatible: true}, d.parent()})
+		}
+	}
+	for key, d := range before {
+		if _, ok := after[key]; ok {
+			continue
+		}
+		changes = append(changes, change{APIChange{Kind: d.kind, Name: d.name, Change: APIRemoved, Old: d.decl, Incompatible: true}, d.parent()})
+		if d.kind == "type" {
+			types[d.name] = true
+		}
+	}
+
+	var result []APIChange
+	for _, c := range changes {
+		if !types[c.parent] {
+			result = append(result, c.APIChange)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
@@ -0,0 +1,195 @@
+package goreadme
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apiDiffV1 = `// Package lib is a library.
+package lib
+
+import "io"
+
+const (
+	A = iota
+	B
+)
+
+// URL is the service URL.
+const URL = "https://example.com"
+
+var Default = New("a")
+
+type Client struct {
+	Name string
+	Port int
+	io.Reader
+}
+
+func New(name string) *Client { return nil }
+
+func (c *Client) Do(req string) error { return nil }
+
+func (c *Client) Close() {}
+
+type Doer interface {
+	Do(req string) error
+}
+
+type Old struct {
+	Field int
+}
+`
+
+const apiDiffV2 = `// Package lib is a library.
+package lib
+
+import "io"
+
+const (
+	A = iota
+	C
+	B
+)
+
+// URL is the service URL.
+const URL = "https://example.com"
+
+var Default = New("a")
+
+type Client struct {
+	Name    string
+	Port    int64
+	Timeout int
+	io.Reader
+}
+
+func New(n string) *Client { return nil }
+
+func (cl *Client) Do(req string) error { return nil }
+
+func (c *Client) Get(url string) (string, error) { return "", nil }
+
+type Doer interface {
+	Do(req string) error
+	Close()
+}
+
+type New2 struct {
+	Field int
+}
+`
+
+func TestAPIDiff(t *testing.T) {
+	t.Parallel()
+
+	dir := apiDiffRepo(t, apiDiffV1, apiDiffV2)
+	buf := bytes.NewBuffer(nil)
+	err := New(nil).APIDiff(context.Background(), filepath.Join(dir, "lib"), "v1", "HEAD", buf)
+	require.NoError(t, err)
+	assert.Equal(t, apiDiffWant, buf.String())
+}
+
+// apiDiffWant is the API diff between apiDiffV1 and apiDiffV2, with backticks written as single
+// quotes. Renamed parameters and receivers are not changes, and the fields of added and removed
+// types are not listed.
+var apiDiffWant = strings.ReplaceAll(`## API Changes
+
+Changes in the exported API of package lib from 'v1' to 'HEAD'.
+
+### Incompatible Changes
+
+* const 'B' changed: 'const B = iota (iota = 1)' to 'const B = iota (iota = 2)'
+* method 'Client.Close' removed: 'func (c *Client) Close()'
+* field 'Client.Port' changed: 'Port int' to 'Port int64'
+* method 'Doer.Close' added: 'Close()'
+* type 'Old' removed: 'type Old struct'
+
+### Compatible Changes
+
+* const 'C' added: 'const C = iota (iota = 1)'
+* method 'Client.Get' added: 'func (c *Client) Get(url string) (string, error)'
+* field 'Client.Timeout' added: 'Timeout int'
+* type 'New2' added: 'type New2 struct'
+`, "'", "`")
+
+func TestAPIDiffNoChanges(t *testing.T) {
+	t.Parallel()
+
+	// Changes in docs, parameter names, function bodies and unexported identifiers.
+	v2 := strings.NewReplacer(
+		"// URL is the service URL.", "// URL is the URL of the service.",
+		"func New(name string) *Client { return nil }", "func New(n string) *Client { return &Client{Name: n} }",
+		"type Old struct", "type old struct{}\n\ntype Old struct",
+	).Replace(apiDiffV1)
+	require.NotEqual(t, apiDiffV1, v2)
+
+	dir := apiDiffRepo(t, apiDiffV1, v2)
+	buf := bytes.NewBuffer(nil)
+	err := New(nil).APIDiff(context.Background(), filepath.Join(dir, "lib"), "v1", "v2", buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "No changes to the exported API.")
+}
+
+func TestAPIDiffBackticks(t *testing.T) {
+	t.Parallel()
+
+	// Backticks in declarations do not end the inline code spans.
+	const v1 = "package lib\n\nconst Pattern = `a`\n"
+	dir := apiDiffRepo(t, v1, strings.Replace(v1, "`a`", "`b`", 1))
+	buf := bytes.NewBuffer(nil)
+	err := New(nil).APIDiff(context.Background(), filepath.Join(dir, "lib"), "v1", "v2", buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "* const `Pattern` changed: `` const Pattern = `a` `` to `` const Pattern = `b` ``\n")
+}
+
+func TestAPIDiffCustomSource(t *testing.T) {
+	t.Parallel()
+
+	src := fakeSource{"example.com/fake": {Name: "fake", ImportPath: "example.com/fake"}}
+	_, err := New(nil).WithSource(src).APIChanges(context.Background(), "example.com/fake", "v1.0.0", "v1.1.0")
+	assert.Error(t, err)
+}
+
+// apiDiffRepo creates a git repository of the example.com/lib module, with a commit of the lib
+// package for each of the given versions of its source, tagged v1, v2 and so on. It returns the
+// repository directory.
+func apiDiffRepo(t *testing.T, versions ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		_, err := git(ctx, dir, args...)
+		require.NoError(t, err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0775))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0664))
+	}
+	run("init")
+	write("go.mod", "module example.com/lib\n")
+	for i, v := range versions {
+		write("lib/lib.go", v)
+		run("add", "-A")
+		run("commit", "-m", fmt.Sprintf("v%d", i+1))
+		run("tag", fmt.Sprintf("v%d", i+1))
+	}
+	return dir
+}
@@ -0,0 +1,160 @@
+package goreadme
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/golang/gddo/doc"
+	"github.com/pkg/errors"
+)
+
+// Badge is a README badge, which is an image with a link. The Alt, Image and Link fields are
+// templates, which can use the `{{importPath}}` function for the package import path, the
+// `{{fullName}}` function for the import path without the "github.com/" prefix, the `{{repo}}`
+// function for the Github "owner/repository" name, the `{{branch}}` function for the default
+// branch, the `{{goDocURL}}` function for the configured Go Doc URL and the `{{sourceURL "path"}}`
+// function for the URL of a file in the repository, according to the source URL pattern.
+type Badge struct {
+	// Name identifies the badge in the badge order.
+	Name  string `json:"name"`
+	Alt   string `json:"alt"`
+	Image string `json:"image"`
+	Link  string `json:"link"`
+}
+
+// builtinBadges are the badges that can be enabled in the Badges config, in their default order.
+var builtinBadges = []struct {
+	Badge
+	enabled func(Config) bool
+}{
+	{
+		Badge:   Badge{Name: "travis_ci", Alt: "Build Status", Image: "https://travis-ci.org/{{fullName}}.svg?branch={{branch}}", Link: "https://travis-ci.org/{{fullName}}"},
+		enabled: func(c Config) bool { return c.Badges.TravisCI },
+	},
+	{
+		Badge:   Badge{Name: "code_cov", Alt: "codecov", Image: "https://codecov.io/gh/{{fullName}}/branch/{{branch}}/graph/badge.svg", Link: "https://codecov.io/gh/{{fullName}}"},
+		enabled: func(c Config) bool { return c.Badges.CodeCov },
+	},
+	{
+		Badge:   Badge{Name: "golang_ci", Alt: "golangci", Image: "https://golangci.com/badges/{{importPath}}.svg", Link: "https://golangci.com/r/{{importPath}}"},
+		enabled: func(c Config) bool { return c.Badges.GolangCI },
+	},
+	{
+		Badge:   Badge{Name: "go_doc", Alt: "GoDoc", Image: "https://pkg.go.dev/badge/pkgsite/pkg.svg", Link: "{{goDocURL}}/{{importPath}}"},
+		enabled: func(c Config) bool { return c.Badges.GoDoc },
+	},
+	{
+		Badge:   Badge{Name: "go_report_card", Alt: "Go Report Card", Image: "https://goreportcard.com/badge/{{importPath}}", Link: "https://goreportcard.com/report/{{importPath}}"},
+		enabled: func(c Config) bool { return c.Badges.GoReportCard },
+	},
+	{
+		Badge:   Badge{Name: "github_actions", Alt: "Build Status", Image: "https://github.com/{{repo}}/actions/workflows/{{workflow}}/badge.svg", Link: "https://github.com/{{repo}}/actions/workflows/{{workflow}}"},
+		enabled: func(c Config) bool { return c.Badges.GithubActions },
+	},
+	{
+		Badge:   Badge{Name: "go_version", Alt: "Go Version", Image: "https://img.shields.io/github/go-mod/go-version/{{repo}}", Link: "{{sourceURL \"go.mod\"}}"},
+		enabled: func(c Config) bool { return c.Badges.GoVersion },
+	},
+	{
+		Badge:   Badge{Name: "license", Alt: "License", Image: "https://img.shields.io/github/license/{{repo}}", Link: "{{sourceURL \"LICENSE\"}}"},
+		enabled: func(c Config) bool { return c.Badges.License },
+	},
+	{
+		Badge:   Badge{Name: "release", Alt: "Release", Image: "https://img.shields.io/github/v/release/{{repo}}", Link: "https://github.com/{{repo}}/releases/latest"},
+		enabled: func(c Config) bool { return c.Badges.Release },
+	},
+}
+
+const defaultWorkflow = "test.yml"
+
+// badges returns the enabled badges and the custom badges of the package, with their templates
+// executed, in the configured order.
+func badges(p *doc.Package, cfg Config) ([]Badge, error) {
+	var all []Badge
+	for _, b := range builtinBadges {
+		if b.enabled(cfg) {
+			all = append(all, b.Badge)
+		}
+	}
+	all = append(all, cfg.CustomBadges...)
+	all = orderBadges(all, cfg.BadgeOrder)
+
+	goDocURL := cfg.GoDocURL
+	if goDocURL == "" {
+		goDocURL = defaultGoDocURL
+	}
+	workflow := cfg.Badges.Workflow
+	if workflow == "" {
+		workflow = defaultWorkflow
+	}
+	sourceURL := func(path string) string {
+		s, ok := newSourceURLs(p, cfg)
+		if !ok {
+			s, _ = newSourceURLs(p, Config{SourceURLPattern: "github", Branch: cfg.Branch})
+		}
+		return s.file(path)
+	}
+	funcs := template.FuncMap{
+		"importPath": func() string { return p.ImportPath },
+		"fullName":   func() string { return strings.TrimPrefix(p.ImportPath, "github.com/") },
+		"repo":       func() string { return githubRepo(p.ImportPath) },
+		"goDocURL":   func() string { return goDocURL },
+		"workflow":   func() string { return workflow },
+		"branch":     func() string { return branch(cfg) },
+		"sourceURL":  sourceURL,
+	}
+	for i, b := range all {
+		for _, field := range []*string{&b.Alt, &b.Image, &b.Link} {
+			var err error
+			*field, err = executeBadgeTemplate(*field, funcs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "badge %q", b.Name)
+			}
+		}
+		all[i] = b
+	}
+	return all, nil
+}
+
+// orderBadges returns the badges ordered by their names in order. Badges that are not in the
+// order keep their relative order after the ordered badges.
+func orderBadges(badges []Badge, order []string) []Badge {
+	if len(order) == 0 {
+		return badges
+	}
+	ordered := make([]Badge, 0, len(badges))
+	used := make([]bool, len(badges))
+	for _, name := range order {
+		for i, b := range badges {
+			if !used[i] && b.Name == name {
+				ordered = append(ordered, b)
+				used[i] = true
+			}
+		}
+	}
+	for i, b := range badges {
+		if !used[i] {
+			ordered = append(ordered, b)
+		}
+	}
+	return ordered
+}
+
+func executeBadgeTemplate(text string, funcs template.FuncMap) (string, error) {
+	t, err := template.New("badge").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	err = t.Execute(&b, nil)
+	return b.String(), err
+}
+
+// githubRepo returns the Github "owner/repository" name of an import path.
+func githubRepo(importPath string) string {
+	parts := strings.SplitN(strings.TrimPrefix(importPath, "github.com/"), "/", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, "/")
+}
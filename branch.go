@@ -0,0 +1,12 @@
+package goreadme
+
+// defaultBranch is used in badges when no branch is configured.
+const defaultBranch = "master"
+
+// branch returns the configured branch, or the default branch.
+func branch(cfg Config) string {
+	if cfg.Branch != "" {
+		return cfg.Branch
+	}
+	return defaultBranch
+}
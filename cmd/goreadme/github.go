@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/posener/goaction/actionutil"
+	"github.com/posener/goaction/log"
+)
+
+// A Github client with the token input, of the REST API of the Github server that runs the
+// action, which is api.github.com unless the action runs on Github Enterprise Server.
+func githubClient(ctx context.Context) *actionutil.Client {
+	c := actionutil.NewClientWithToken(ctx, githubToken)
+	if apiURL := ciEnv("GITHUB_API_URL"); apiURL != "" {
+		u, err := url.Parse(strings.TrimSuffix(apiURL, "/") + "/")
+		if err != nil {
+			log.Fatalf("Invalid GITHUB_API_URL %q: %s", apiURL, err)
+		}
+		c.BaseURL = u
+	}
+	return c
+}
+
+// URL of the GraphQL API of the Github server that runs the action. It is derived from the REST API
+// URL if the GraphQL API URL is not set. An empty URL is the GraphQL API of api.github.com.
+func githubGraphQLURL() string {
+	if u := ciEnv("GITHUB_GRAPHQL_URL"); u != "" {
+		return u
+	}
+	apiURL := strings.TrimSuffix(ciEnv("GITHUB_API_URL"), "/")
+	if apiURL == "" {
+		return ""
+	}
+	// The REST API of Github Enterprise Server is served under /api/v3, and its GraphQL API
+	// under /api/graphql.
+	return strings.TrimSuffix(apiURL, "/v3") + "/graphql"
+}
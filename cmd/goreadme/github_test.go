@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubGraphQLURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiURL     string
+		graphQLURL string
+		want       string
+	}{
+		{name: "default"},
+		{name: "github.com", apiURL: "https://api.github.com", want: "https://api.github.com/graphql"},
+		{name: "enterprise server", apiURL: "https://github.example.com/api/v3/", want: "https://github.example.com/api/graphql"},
+		{
+			name:       "graphql url",
+			apiURL:     "https://github.example.com/api/v3",
+			graphQLURL: "https://graphql.example.com",
+			want:       "https://graphql.example.com",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_API_URL", tt.apiURL)
+			t.Setenv("GITHUB_GRAPHQL_URL", tt.graphQLURL)
+			assert.Equal(t, tt.want, githubGraphQLURL())
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/posener/goaction/actionutil"
+	"github.com/posener/goaction/log"
+	"github.com/posener/goreadme/internal/gitlab"
+	"github.com/posener/goreadme/internal/prcomment"
+)
+
+// Base URL of the GitLab REST API.
+var gitlabAPIURL string
+
+// GitLab CI mode is detected by the predefined GitLab CI variables.
+func gitlabCI() bool {
+	return ciEnv("GITLAB_CI") == "true"
+}
+
+// Access token for merge request notes and for pushing changes. A project access token with the
+// api and write_repository scopes.
+func gitlabToken() string {
+	return ciEnv("GITLAB_TOKEN")
+}
+
+// The import path of the project.
+func gitlabImportPath() string {
+	return ciEnv("CI_SERVER_HOST") + "/" + ciEnv("CI_PROJECT_PATH")
+}
+
+// The default branch of the project.
+func gitlabBranch() string {
+	return ciEnv("CI_DEFAULT_BRANCH")
+}
+
+func gitlabDefaultAPIURL() string {
+	if u := ciEnv("CI_API_V4_URL"); u != "" {
+		return u
+	}
+	return gitlab.DefaultBaseURL
+}
+
+// GitLab CI pipelines that goreadme acts on.
+const (
+	gitlabMergeRequest  = "merge request"
+	gitlabDefaultBranch = "default branch"
+)
+
+// The kind of the GitLab CI pipeline, from the predefined GitLab CI variables, or an empty string
+// if goreadme does not act on it.
+func gitlabPipeline() string {
+	branch := ciEnv("CI_COMMIT_BRANCH")
+	switch {
+	case ciEnv("CI_MERGE_REQUEST_IID") != "":
+		return gitlabMergeRequest
+	case branch != "" && branch == ciEnv("CI_DEFAULT_BRANCH"):
+		return gitlabDefaultBranch
+	default:
+		return ""
+	}
+}
+
+// Runs in GitLab CI mode: post the diff as a note on merge request pipelines, and commit and push
+// the changes on default branch pipelines.
+func runGitLab(ctx context.Context) {
+	diff := formatDiffs(gitDiffs())
+
+	log.Printf("Diff:\n\n%s\n", diff)
+
+	switch gitlabPipeline() {
+	case gitlabMergeRequest:
+		mergeRequestNote(ctx, diff)
+	case gitlabDefaultBranch:
+		if diff == "" {
+			log.Printf("No changes were made. Skipping push.")
+			return
+		}
+		if gitlabToken() == "" {
+			log.Fatalf("In order to push changes, set the GITLAB_TOKEN variable.")
+		}
+		if err := gitlabPush(ctx, gitlabRemote(), ciEnv("CI_COMMIT_BRANCH")); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Printf("Not a merge request or a default branch pipeline. Skipping.")
+	}
+}
+
+// Post a merge request note with the expected diff. Like the Github pull request comment, the note
+// of previous runs is edited instead of posting a new one.
+func mergeRequestNote(ctx context.Context, diff string) {
+	if gitlabToken() == "" {
+		log.Printf("In order to add a merge request note, set the GITLAB_TOKEN variable.")
+		return
+	}
+	c := &gitlab.Client{BaseURL: gitlabAPIURL, Token: gitlabToken()}
+	err := c.PostStickyNote(ctx, ciEnv("CI_PROJECT_PATH"), ciEnv("CI_MERGE_REQUEST_IID"), prcomment.Marker, commentBody(diff, "merge request"))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// The URL of the project repository, with the token credentials.
+func gitlabRemote() string {
+	remote, err := url.Parse(ciEnv("CI_SERVER_URL"))
+	if err != nil {
+		log.Fatalf("Invalid CI_SERVER_URL: %s", err)
+	}
+	remote.User = url.UserPassword("oauth2", gitlabToken())
+	remote.Path = "/" + ciEnv("CI_PROJECT_PATH") + ".git"
+	return remote.String()
+}
+
+// Commit the changed files and push them to the branch of the remote repository. The push skips
+// the CI pipeline of the commit.
+func gitlabPush(ctx context.Context, remote, branch string) error {
+	err := actionutil.GitConfig("goreadme", email)
+	if err != nil {
+		return err
+	}
+	for _, args := range [][]string{
+		append([]string{"add"}, files...),
+		{"commit", "-m", "Update readme according to godoc"},
+		{"push", "-o", "ci.skip", remote, "HEAD:" + branch},
+	} {
+		if err := runGit(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,19 +2,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
-	"github.com/golang/gddo/gosrc"
 	"github.com/posener/goaction"
 	"github.com/posener/goaction/actionutil"
 	"github.com/posener/goaction/log"
 	"github.com/posener/goreadme"
+	"github.com/posener/goreadme/internal/prcomment"
+	"github.com/posener/goreadme/internal/updatepr"
 	"golang.org/x/oauth2"
 )
 
@@ -25,6 +30,32 @@ var (
 	// Write readme output
 	out io.WriteCloser = os.Stdout
 
+	// Check that the readme file is up to date instead of writing it.
+	check bool
+	// Write a readme file for every package in the module.
+	all bool
+	// Readme files that were written.
+	files []string
+	// How changes are applied on push events: push, pull-request or check.
+	mode string
+	// How changes are applied on schedule, workflow_dispatch and release events. Empty values
+	// default to mode.
+	onSchedule, onWorkflowDispatch, onRelease string
+	// What to do with the pull request comment when there are no changes: update, delete or
+	// resolve.
+	prCommentClean string
+	// Put the diff in the pull request comment in a collapsible block.
+	prCommentDetails bool
+	// Add the changes in the exported API to the pull request comment.
+	prAPIDiff bool
+	// Comma separated badge names to show first, which override the config badge order if set.
+	badgeOrder string
+
+	// Command line arguments, without the subcommand.
+	args []string
+	// The old and new refs of the apidiff subcommand.
+	apiDiffRefs []string
+
 	// Github action variables.
 	//goaction:description Name of readme file.
 	//goaction:default README.md
@@ -47,7 +78,11 @@ var (
 func init() {
 	flag.StringVar(&cfg.ImportPath, "import-path", "", "Override package import path.")
 	flag.StringVar(&cfg.Title, "title", "", "Override readme title. Default is package name.")
+	flag.StringVar(&cfg.Branch, "branch", "", "Default branch of the repository, for badges and source links. Detected from git if not set.")
+	flag.StringVar(&cfg.Ref, "ref", "", "Tag, branch or commit of a remote package to render, loaded from the Go module proxy. Default is the default branch.")
+	flag.StringVar(&cfg.SourceURLPattern, "source-url-pattern", "", "Source link URL pattern with {host}, {repo}, {ref}, {refType}, {path} and {line} placeholders, or a preset: github, gitlab, bitbucket, gitea or sourcehut. Default is the preset of the import path host.")
 	flag.StringVar(&cfg.GoDocURL, "godoc-url", "https://pkg.go.dev", "Go Doc URL for GoDoc badge.")
+	flag.BoolVar(&cfg.StdMarkdown, "std-markdown", false, "Use the Go doc comment syntax of the standard library, including doc links, to render the doc.")
 	flag.BoolVar(&cfg.RecursiveSubPackages, "recursive", false, "Load docs recursively.")
 	flag.BoolVar(&cfg.RenderTypeContent, "render-type-content", false, "If 'types' is specified, render full type content.")
 	flag.BoolVar(&cfg.Consts, "constants", false, "Write package constants section, and if 'types' is specified, also write per-type constants section.")
@@ -58,13 +93,37 @@ func init() {
 	flag.BoolVar(&cfg.Methods, "methods", false, "If 'types' is specified, write section for methods for each type.")
 	flag.BoolVar(&cfg.SkipExamples, "skip-examples", false, "Skip the examples section.")
 	flag.BoolVar(&cfg.SkipSubPackages, "skip-sub-packages", false, "Skip the sub packages section.")
+	flag.BoolVar(&cfg.Flags, "flags", false, "Write the flags section of commands.")
+	flag.BoolVar(&cfg.Installation, "installation", false, "Write installation section.")
+	flag.StringVar(&cfg.Deprecated, "deprecated", "", "How deprecated identifiers are rendered: 'hide' omits them, 'section' moves them to a Deprecated section and 'mark' labels their headings with the replacement advice. Default is to render them like other identifiers.")
 	flag.BoolVar(&cfg.Badges.TravisCI, "badge-travisci", false, "Show TravisCI badge.")
 	flag.BoolVar(&cfg.Badges.CodeCov, "badge-codecov", false, "Show CodeCov badge.")
 	flag.BoolVar(&cfg.Badges.GolangCI, "badge-golangci", false, "Show GolangCI badge.")
 	flag.BoolVar(&cfg.Badges.GoDoc, "badge-godoc", false, "Show GoDoc badge.")
 	flag.BoolVar(&cfg.Badges.GoReportCard, "badge-goreportcard", false, "Show GoReportCard badge.")
+	flag.BoolVar(&cfg.Badges.GithubActions, "badge-github-actions", false, "Show Github Actions workflow status badge.")
+	flag.StringVar(&cfg.Badges.Workflow, "badge-workflow", "test.yml", "Github Actions workflow file name for the Github Actions badge.")
+	flag.BoolVar(&cfg.Badges.GoVersion, "badge-go-version", false, "Show Go version badge.")
+	flag.BoolVar(&cfg.Badges.License, "badge-license", false, "Show license badge.")
+	flag.BoolVar(&cfg.Badges.Release, "badge-release", false, "Show latest release badge.")
+	flag.StringVar(&badgeOrder, "badge-order", "", "Comma separated badge names, such as 'go_doc,license', to show first in this order.")
 	flag.BoolVar(&cfg.GeneratedNotice, "generated-notice", false, "Add generated file notice (visible only in Markdown code).")
 	flag.BoolVar(&cfg.Credit, "credit", true, "Add credit line.")
+	flag.BoolVar(&cfg.Markers, "markers", false, "Wrap sections with goreadme markers, and update only the marked content of an existing readme file.")
+	flag.BoolVar(&cfg.TOC, "toc", false, "Add a table of contents after the package doc.")
+	flag.IntVar(&cfg.TOCDepth, "toc-depth", 4, "Deepest heading level in the table of contents.")
+	flag.StringVar(&cfg.APIFile, "api-file", "", "Write the constants, variables, functions and types sections to this API reference file, relative to the readme file, instead of the readme file.")
+	flag.StringVar(&cfg.TemplateDir, "template-dir", "", "Directory with template files that override the built-in templates.")
+	flag.BoolVar(&check, "check", false, "Check that the readme file is up to date: print a diff and fail if it is not. Default file is README.md.")
+	flag.BoolVar(&all, "all", false, "Write a readme file in the directory of every package in the module. The readme file name is relative to each package directory.")
+	flag.StringVar(&mode, "mode", "push", "How changes are applied on push events: 'push' commits them to the pushed branch, 'pull-request' opens a pull request from the goreadme/update-readme branch, and 'check' fails if there are changes.")
+	flag.StringVar(&onSchedule, "on-schedule", "", "How changes are applied on schedule events: 'push', 'pull-request' or 'check'. Default is the mode flag.")
+	flag.StringVar(&onWorkflowDispatch, "on-workflow-dispatch", "", "How changes are applied on workflow_dispatch events: 'push', 'pull-request' or 'check'. Default is the mode flag.")
+	flag.StringVar(&onRelease, "on-release", "pull-request", "How changes are applied on release events: 'pull-request' to the default branch or 'check'.")
+	flag.StringVar(&prCommentClean, "pr-comment-clean", "update", "What to do with the pull request comment when the readme is not changed: 'update' it, 'delete' it or 'resolve' it.")
+	flag.BoolVar(&prCommentDetails, "pr-comment-details", false, "Put the diff in the pull request comment in a collapsible details block.")
+	flag.BoolVar(&prAPIDiff, "pr-apidiff", false, "Add the changes in the exported API of the package, compared to the base branch, to the pull request comment. Requires the base branch to be fetched.")
+	flag.StringVar(&gitlabAPIURL, "gitlab-api-url", "", "Base URL of the GitLab REST API, for merge request notes in GitLab CI mode. Default is the CI_API_V4_URL variable or gitlab.com.")
 	flag.Usage = func() {
 		fmt.Fprint(
 			flag.CommandLine.Output(),
@@ -72,52 +131,193 @@ func init() {
 
 Usage:
 	goreadme [flags] [import path]
+	goreadme [flags] apidiff <old ref> <new ref> [import path]
 
 import path (optional): Create a readme file for a package from github.
  Omitting import path will create a readme for the package in CWD.
+apidiff: Print the changes in the exported API of the package between two
+ git refs, or two module versions of a remote package, as markdown.
 Flags:
 `)
 		flag.PrintDefaults()
 	}
+}
+
+// Parse the command line flags and the config file. It is called from main rather than from init,
+// so the flags are not parsed in tests.
+func parseFlags() {
 	flag.Parse()
 
+	args = flag.Args()
+	if len(args) > 0 && args[0] == "apidiff" {
+		if len(args) < 3 {
+			log.Fatalf("Usage: goreadme apidiff <old ref> <new ref> [import path]")
+		}
+		apiDiffRefs, args = args[1:3], args[3:]
+	}
+
 	if path == "" {
 		path = path2
 	}
 	if githubToken == "" {
 		githubToken = githubToken2
 	}
+	if gitlabAPIURL == "" {
+		gitlabAPIURL = gitlabDefaultAPIURL()
+	}
+
+	validateChoice("mode", mode, "push", "pull-request", "check")
+	if onSchedule == "" {
+		onSchedule = mode
+	}
+	validateChoice("on-schedule", onSchedule, "push", "pull-request", "check")
+	if onWorkflowDispatch == "" {
+		onWorkflowDispatch = mode
+	}
+	validateChoice("on-workflow-dispatch", onWorkflowDispatch, "push", "pull-request", "check")
+	// A release runs on a tag, so the changes can't be pushed.
+	validateChoice("on-release", onRelease, "pull-request", "check")
+	validateChoice("pr-comment-clean", prCommentClean, "update", "delete", "resolve")
+
+	loadConfigFile()
+	setBadgeOrder()
+	validateDeprecated()
 }
 
-func main() {
-	// Steps to do only in Github Action mode.
-	if path != "" {
-		// Setup output file.
-		var err error
-		out, err = os.Create(path)
-		if err != nil {
-			log.Fatalf("Failed opening file %s: %s", path, err)
-		}
-		defer out.Close()
+// Load the config file closest to the package directory. Flags that were set in the command line
+// override the values from the config file. With the -all flag, the config file of every package
+// is loaded when its readme file is written.
+func loadConfigFile() {
+	if all {
+		return
 	}
-	if goaction.CI {
-		// Fix import path if it was not overridden by the user.
-		if cfg.ImportPath == "" {
-			cfg.ImportPath = "github.com/" + goaction.Repository
+	dir := pkg(args)
+	if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+		// Not a local package.
+		return
+	}
+	file, err := goreadme.FindConfig(dir)
+	if err != nil {
+		log.Fatalf("Failed looking for config file: %s", err)
+	}
+	if file == "" {
+		return
+	}
+
+	set := setFlags()
+	log.Printf("Using config file %s", file)
+	if err := goreadme.LoadConfig(file, &cfg); err != nil {
+		log.Fatalf("Failed loading config: %s", err)
+	}
+	rebaseAPIFile(file)
+	applyFlags(set)
+}
+
+// The flags that were set in the command line, and their values.
+func setFlags() map[string]string {
+	set := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		// The Github action passes all the inputs as flags, so only inputs that were changed from
+		// their default value override the config file.
+		if goaction.CI && f.Value.String() == f.DefValue {
+			return
+		}
+		set[f.Name] = f.Value.String()
+	})
+	return set
+}
+
+// Set the given flags again, over the values that were loaded from a config file.
+func applyFlags(set map[string]string) {
+	for name, value := range set {
+		if err := flag.Set(name, value); err != nil {
+			log.Fatalf("Failed setting flag %s: %s", name, err)
 		}
 	}
+}
 
-	ctx := context.Background()
-	client := http.DefaultClient
-	if githubToken != "" {
-		client = oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: githubToken}))
+// Override the config of a package, which was loaded from its config file, with the flags that
+// were set in the command line. The flags are bound to the global config, so it is swapped with
+// the package config while they are applied.
+func overrideFlags(set map[string]string) func(*goreadme.Config) {
+	return func(c *goreadme.Config) {
+		saved := cfg
+		cfg = *c
+		applyFlags(set)
+		setBadgeOrder()
+		validateDeprecated()
+		*c = cfg
+		cfg = saved
+	}
+}
+
+// Split the badge order flag, which is a plain string flag so it is an input of the Github action.
+func setBadgeOrder() {
+	if badgeOrder != "" {
+		cfg.BadgeOrder = strings.Split(badgeOrder, ",")
+	}
+}
+
+// Validate the config after merging the config file.
+func validateDeprecated() {
+	if cfg.Deprecated != "" {
+		validateChoice("deprecated", cfg.Deprecated, goreadme.DeprecatedHide, goreadme.DeprecatedSection, goreadme.DeprecatedMark)
 	}
-	gr := goreadme.New(client)
+}
 
-	err := gr.WithConfig(cfg).Create(ctx, pkg(flag.Args()), out)
+// A relative api_file in the config file is relative to the config file directory, while the
+// API file is written relative to the readme file directory. With the -all flag, it is relative to
+// each readme file directory, like the readme file name.
+func rebaseAPIFile(file string) {
+	var fileCfg goreadme.Config
+	if err := goreadme.LoadConfig(file, &fileCfg); err != nil {
+		log.Fatalf("Failed loading config: %s", err)
+	}
+	apiFile := fileCfg.APIFile
+	if apiFile == "" || filepath.IsAbs(apiFile) {
+		return
+	}
+	readmeDir, err := filepath.Abs(filepath.Dir(path))
 	if err != nil {
-		log.Fatalf("Failed: %s", err)
+		log.Fatalf("Failed getting readme directory: %s", err)
+	}
+	rel, err := filepath.Rel(readmeDir, filepath.Join(filepath.Dir(file), apiFile))
+	if err != nil {
+		log.Fatalf("Failed resolving api_file %s: %s", apiFile, err)
+	}
+	cfg.APIFile = rel
+}
+
+func main() {
+	parseFlags()
+	ctx := context.Background()
+	gr := newGoReadme(ctx)
+
+	if apiDiffRefs != nil {
+		if err := gr.APIDiff(ctx, pkg(args), apiDiffRefs[0], apiDiffRefs[1], os.Stdout); err != nil {
+			log.Fatalf("Failed: %s", err)
+		}
+		return
+	}
+
+	if check {
+		checkReadme(ctx, gr)
+		return
+	}
+
+	if goaction.CI && goaction.Event == goaction.EventRelease && onRelease == "pull-request" {
+		checkoutDefaultBranch(ctx)
+	}
+
+	if all {
+		files = writeAll(ctx, gr)
+	} else {
+		files = writeReadme(ctx, gr)
+	}
+
+	if gitlabCI() {
+		runGitLab(ctx)
+		return
 	}
 
 	if !goaction.CI {
@@ -126,47 +326,321 @@ func main() {
 
 	// Runs only in Github CI mode.
 
-	diff := gitDiff()
+	diffs := gitDiffs()
+	diff := formatDiffs(diffs)
 
 	log.Printf("Diff:\n\n%s\n", diff)
+	report(ctx, diffs, diff)
 
 	switch goaction.Event {
 	case goaction.EventPush:
+		apply(ctx, mode, goaction.Branch(), diff)
+	case goaction.EventPullRequest:
+		pr(diff, prAPIChanges(ctx, gr))
+	case goaction.EventSchedule:
+		apply(ctx, onSchedule, goaction.Branch(), diff)
+	case eventWorkflowDispatch:
+		apply(ctx, onWorkflowDispatch, goaction.Branch(), diff)
+	case goaction.EventRelease:
+		apply(ctx, onRelease, releaseDefaultBranch(), diff)
+	default:
+		log.Fatalf("Unexpected action mode: %s", goaction.Event)
+	}
+}
+
+// The workflow_dispatch event, which is not defined in goaction.
+const eventWorkflowDispatch goaction.EventType = "workflow_dispatch"
+
+// Apply the changes according to the given action: push them to the current branch, open a pull
+// request to the base branch, or fail if there are changes.
+func apply(ctx context.Context, action, base, diff string) {
+	switch action {
+	case "push":
 		if diff == "" {
 			log.Printf("No changes were made. Skipping push.")
-			break
+			return
 		}
 		push()
-	case goaction.EventPullRequest:
-		pr(diff)
-	default:
-		log.Fatalf("Unexpected action mode: %s", goaction.Event)
+	case "pull-request":
+		pullRequest(ctx, base, diff)
+	case "check":
+		if diff != "" {
+			log.Fatalf("The readme files are not up to date with the Go doc.")
+		}
 	}
 }
 
-func pkg(args []string) string {
-	if len(args) > 0 {
-		return args[0]
+// The default branch of the repository in a release event.
+func releaseDefaultBranch() string {
+	release, err := goaction.GetRelease()
+	if err != nil {
+		log.Fatalf("Failed reading release event: %s", err)
 	}
+	return release.GetRepo().GetDefaultBranch()
+}
 
-	path, err := filepath.Abs("./")
+// Check out the head of the default branch in a release event, instead of the released tag. The
+// pull request is opened to the default branch, so its readme files are generated from this
+// branch, and the pull request does not revert the changes that were made after the release.
+func checkoutDefaultBranch(ctx context.Context) {
+	branch := releaseDefaultBranch()
+	for _, args := range [][]string{
+		{"fetch", "origin", branch},
+		{"checkout", "--detach", "FETCH_HEAD"},
+	} {
+		if err := runGit(ctx, args...); err != nil {
+			log.Fatalf("Failed checking out %s: %s", branch, err)
+		}
+	}
+}
+
+// Exit with an error if the value of the flag is not one of the choices.
+func validateChoice(name, value string, choices ...string) {
+	for _, c := range choices {
+		if value == c {
+			return
+		}
+	}
+	log.Fatalf("Invalid -%s value %q, expected one of: %s.", name, value, strings.Join(choices, ", "))
+}
+
+func newGoReadme(ctx context.Context) *goreadme.GoReadme {
+	// Fix import path if it was not overridden by the user.
+	if cfg.ImportPath == "" {
+		switch {
+		case goaction.CI:
+			cfg.ImportPath = "github.com/" + goaction.Repository
+		case gitlabCI():
+			cfg.ImportPath = gitlabImportPath()
+		}
+	}
+	if cfg.Branch == "" {
+		cfg.Branch = detectBranch(ctx)
+	}
+
+	client := http.DefaultClient
+	if githubToken != "" {
+		client = oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: githubToken}))
+	}
+	return goreadme.New(client).WithConfig(cfg)
+}
+
+// Detect the default branch of the repository, which badges and source links point to, also when
+// running on another branch: from the event payload in Github action mode, from the CI variables
+// in GitLab CI mode, and otherwise from the remote of the local git checkout. If it is not known,
+// the branch is not set.
+func detectBranch(ctx context.Context) string {
+	if gitlabCI() {
+		if branch := gitlabBranch(); branch != "" {
+			return branch
+		}
+	}
+	if goaction.CI {
+		if branch := eventDefaultBranch(); branch != "" {
+			return branch
+		}
+		// Scheduled workflows, which have no repository in their payload, run on the default
+		// branch.
+		if goaction.Event == goaction.EventSchedule {
+			if ref := ciEnv("GITHUB_REF"); strings.HasPrefix(ref, "refs/heads/") {
+				return strings.TrimPrefix(ref, "refs/heads/")
+			}
+		}
+	}
+	dir := pkg(args)
+	if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+		// Not a local package.
+		return ""
+	}
+	branch, err := gitDefaultBranch(ctx, dir)
 	if err != nil {
-		log.Fatal(err)
+		log.Debugf("Failed detecting branch: %s", err)
+		return ""
 	}
-	gosrc.SetLocalDevMode(path)
-	return "."
+	return branch
 }
 
-func gitDiff() string {
-	// Add files to git, in case it does not exists
-	d, err := actionutil.GitDiff(path)
+// The default branch of the git checkout in dir, which the remote HEAD of origin points to. The
+// branch that is checked out is not used, since it may be a feature branch: then the badges and
+// source links would depend on the branch that goreadme runs on.
+func gitDefaultBranch(ctx context.Context, dir string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output()
 	if err != nil {
-		log.Fatal(err)
+		return "", fmt.Errorf("git symbolic-ref: %s", err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "origin/"), nil
+}
+
+// The default branch of the repository from the payload of the Github event, if it has one.
+func eventDefaultBranch() string {
+	f, err := os.Open(ciEnv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		log.Debugf("Failed reading event payload: %s", err)
+		return ""
 	}
-	if d == "" {
+	defer f.Close()
+	var event struct {
+		Repository struct {
+			DefaultBranch string `json:"default_branch"`
+		} `json:"repository"`
+	}
+	if err := json.NewDecoder(f).Decode(&event); err != nil {
+		log.Debugf("Failed parsing event payload: %s", err)
 		return ""
 	}
-	return fmt.Sprintf("Path: %s\n\n```diff\n%s\n```\n\n", path, d)
+	return event.Repository.DefaultBranch
+}
+
+// Write the readme to the output, and the API reference file if configured. Returns the written
+// files.
+func writeReadme(ctx context.Context, gr *goreadme.GoReadme) []string {
+	// Existing readme content, in which only the marked sections are updated.
+	var existing []byte
+	// Steps to do only in Github Action mode.
+	if path != "" {
+		var err error
+		if cfg.Markers {
+			existing, err = os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				log.Fatalf("Failed reading file %s: %s", path, err)
+			}
+		}
+		// Setup output file.
+		out, err = os.Create(path)
+		if err != nil {
+			log.Fatalf("Failed opening file %s: %s", path, err)
+		}
+		defer out.Close()
+	}
+
+	var err error
+	if len(existing) > 0 {
+		err = gr.Update(ctx, pkg(args), bytes.NewReader(existing), out)
+	} else {
+		err = gr.Create(ctx, pkg(args), out)
+	}
+	if err != nil {
+		log.Fatalf("Failed: %s", err)
+	}
+	written := []string{path}
+
+	if cfg.APIFile != "" {
+		apiPath := filepath.Join(filepath.Dir(path), cfg.APIFile)
+		f, err := os.Create(apiPath)
+		if err != nil {
+			log.Fatalf("Failed opening file %s: %s", apiPath, err)
+		}
+		defer f.Close()
+		if path != "" && cfg.APIReadmeLink == "" {
+			gr = gr.WithConfig(withReadmeLink(cfg, apiPath))
+		}
+		err = gr.CreateAPI(ctx, pkg(args), f)
+		if err != nil {
+			log.Fatalf("Failed: %s", err)
+		}
+		written = append(written, apiPath)
+	}
+	return written
+}
+
+// Set the link from the API reference file in apiPath to the readme file.
+func withReadmeLink(cfg goreadme.Config, apiPath string) goreadme.Config {
+	apiDir, err := filepath.Abs(filepath.Dir(apiPath))
+	if err != nil {
+		log.Fatalf("Failed getting API reference file directory: %s", err)
+	}
+	readme, err := filepath.Abs(path)
+	if err != nil {
+		log.Fatalf("Failed getting readme file path: %s", err)
+	}
+	link, err := filepath.Rel(apiDir, readme)
+	if err != nil {
+		log.Fatalf("Failed linking %s to %s: %s", apiPath, path, err)
+	}
+	cfg.APIReadmeLink = filepath.ToSlash(link)
+	return cfg
+}
+
+// Write a readme file for every package in the module, and print the changed files.
+func writeAll(ctx context.Context, gr *goreadme.GoReadme) []string {
+	fileName := path
+	if fileName == "" {
+		fileName = "README.md"
+	}
+	changed, err := gr.WithConfigFiles(overrideFlags(setFlags())).WriteAll(ctx, pkg(args), fileName)
+	if err != nil {
+		log.Fatalf("Failed: %s", err)
+	}
+	if len(changed) == 0 {
+		fmt.Println("All readme files are up to date.")
+		return nil
+	}
+	fmt.Printf("Updated %d readme files:\n", len(changed))
+	for _, f := range changed {
+		fmt.Printf("  %s\n", f)
+	}
+	return changed
+}
+
+// Compare the readme file with the generated readme, and exit with an error if they differ.
+func checkReadme(ctx context.Context, gr *goreadme.GoReadme) {
+	checkPath := path
+	if checkPath == "" {
+		checkPath = "README.md"
+	}
+	f, err := os.Open(checkPath)
+	if err != nil {
+		log.Fatalf("Failed opening file %s: %s", checkPath, err)
+	}
+	defer f.Close()
+
+	diff, err := gr.Check(ctx, pkg(args), f)
+	if err != nil {
+		log.Fatalf("Failed: %s", err)
+	}
+	if diff != "" {
+		fmt.Print(diff)
+		log.Fatalf("%s is not up to date with the Go doc.", checkPath)
+	}
+}
+
+func pkg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "."
+}
+
+// A diff of a readme file.
+type fileDiff struct {
+	path string
+	diff string
+}
+
+// Diffs of the readme files that were changed.
+func gitDiffs() []fileDiff {
+	var diffs []fileDiff
+	for _, f := range files {
+		// Add files to git, in case it does not exists
+		d, err := actionutil.GitDiff(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if d == "" {
+			continue
+		}
+		diffs = append(diffs, fileDiff{path: f, diff: d})
+	}
+	return diffs
+}
+
+func formatDiffs(diffs []fileDiff) string {
+	var diff strings.Builder
+	for _, d := range diffs {
+		fmt.Fprintf(&diff, "Path: %s\n\n```diff\n%s\n```\n\n", d.path, d.diff)
+	}
+	return diff.String()
 }
 
 // Commit and push changes to upstream branch.
@@ -176,30 +650,147 @@ func push() {
 		log.Fatal(err)
 	}
 
-	err = actionutil.GitCommitPush([]string{path}, "Update readme according to godoc")
+	err = actionutil.GitCommitPush(files, "Update readme according to godoc")
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-// Post a pull request comment with the expected diff.
-func pr(diff string) {
+// Commit the changes to the update branch and open a pull request to the base branch, or close the
+// open pull request if there are no changes.
+func pullRequest(ctx context.Context, base, diff string) {
 	if githubToken == "" {
-		log.Printf("In order to add request comment, set the GITHUB_TOKEN input.")
+		log.Fatalf("In order to open a pull request, set the GITHUB_TOKEN input.")
+	}
+	p := &updatepr.PR{
+		Client: githubClient(ctx),
+		Head:   updatepr.DefaultBranch,
+		Base:   base,
+	}
+
+	if diff == "" {
+		pr, err := p.Close(ctx, "The readme files are up to date.")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if pr != nil {
+			log.Printf("Closed pull request %s", pr.GetHTMLURL())
+		}
 		return
 	}
 
-	body := "[goreadme](https://github.com/posener/goreadme) will not make any changes in this PR"
-	if diff != "" {
-		body = fmt.Sprintf(
-			"[goreadme](https://github.com/posener/goreadme) diff for %s file for this PR:\n\n%s",
-			path,
-			diff)
+	err := actionutil.GitConfig("goreadme", email)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"checkout", "-B", p.Head},
+		append([]string{"add"}, files...),
+		{"commit", "-m", "Update readme according to godoc"},
+		{"push", "--force", "origin", p.Head},
+	} {
+		if err := runGit(ctx, args...); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	body := fmt.Sprintf(
+		"[goreadme](https://github.com/posener/goreadme) updates %s according to the Go doc:\n\n%s",
+		strings.Join(files, ", "),
+		diff)
+	pr, err := p.Open(ctx, "Update readme according to godoc", body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Opened pull request %s", pr.GetHTMLURL())
+}
+
+// Reads an environment variable of the CI service. Unlike os.Getenv calls, which goaction
+// declares as inputs of the Github action, the variable is not declared as an input.
+func ciEnv(name string) string {
+	value, _ := os.LookupEnv(name)
+	return value
+}
+
+// Run a git command in the current directory.
+func runGit(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return nil
+}
+
+// The changes in the exported API between the pull request base branch and head, if the
+// -pr-apidiff flag is set and there are any.
+func prAPIChanges(ctx context.Context, gr *goreadme.GoReadme) string {
+	if !prAPIDiff {
+		return ""
+	}
+	base, head := "origin/"+ciEnv("GITHUB_BASE_REF"), "HEAD"
+	report, err := gr.APIChanges(ctx, pkg(args), base, head)
+	if err != nil {
+		log.Fatalf("Failed comparing the API with %s: %s", base, err)
+	}
+	if len(report.Changes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	if err := gr.WriteAPIDiff(&b, report); err != nil {
+		log.Fatalf("Failed writing the API changes: %s", err)
+	}
+	return b.String()
+}
+
+// Post a pull request comment with the expected diff and API changes. The comment of previous
+// runs is edited instead of posting a new one. When there are no changes, the comment is updated,
+// deleted or resolved according to the -pr-comment-clean flag.
+func pr(diff, apiChanges string) {
+	if githubToken == "" {
+		log.Printf("In order to add request comment, set the GITHUB_TOKEN input.")
+		return
 	}
 
 	ctx := context.Background()
-	err := actionutil.PRComment(ctx, githubToken, body)
+	c := &prcomment.Comment{
+		Client:     githubClient(ctx),
+		GraphQLURL: githubGraphQLURL(),
+		Number:     goaction.PrNum(),
+	}
+	var err error
+	switch {
+	case diff != "" || apiChanges != "":
+		body := commentBody(diff, "PR")
+		if apiChanges != "" {
+			body += "\n\n" + apiChanges
+		}
+		err = c.Post(ctx, body)
+	case prCommentClean == "delete":
+		err = c.Delete(ctx)
+	case prCommentClean == "resolve":
+		err = c.Resolve(ctx, commentBody("", "PR"))
+	default:
+		err = c.Post(ctx, commentBody("", "PR"))
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// The body of a pull request comment or a merge request note with the expected diff. request is
+// how the request is called in the comment: "PR" or "merge request".
+func commentBody(diff, request string) string {
+	if diff == "" {
+		return "[goreadme](https://github.com/posener/goreadme) will not make any changes in this " + request
+	}
+	if prCommentDetails {
+		diff = fmt.Sprintf("<details>\n<summary>Diff</summary>\n\n%s</details>\n", diff)
+	}
+	return fmt.Sprintf(
+		"[goreadme](https://github.com/posener/goreadme) diff for %s file for this %s:\n\n%s",
+		strings.Join(files, ", "),
+		request,
+		diff)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitDefaultBranch(t *testing.T) {
+	ctx := context.Background()
+
+	origin := t.TempDir()
+	git(t, origin, "init", "--initial-branch=main")
+	git(t, origin, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init")
+
+	// A repository without a remote has no known default branch.
+	_, err := gitDefaultBranch(ctx, origin)
+	assert.Error(t, err)
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	git(t, "", "clone", origin, clone)
+	// The checked out branch is not the default branch.
+	git(t, clone, "checkout", "-b", "feature")
+
+	got, err := gitDefaultBranch(ctx, clone)
+	require.NoError(t, err)
+	assert.Equal(t, "main", got)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/posener/goaction/log"
+)
+
+// Set the step outputs and write the job summary of the readme changes in Github action mode.
+func report(ctx context.Context, diffs []fileDiff, diff string) {
+	changed := "false"
+	if len(diffs) > 0 {
+		changed = "true"
+	}
+	readmePath := path
+	if readmePath == "" {
+		readmePath = "README.md"
+	}
+	// The outputs are written to the output file, which supports multiline values, and not with
+	// goaction.Output, which declares them in action.yml but sets them with the deprecated
+	// set-output command. They are therefore not declared in action.yml, and are available to
+	// following steps without being declared.
+	err := appendFile(ciEnv("GITHUB_OUTPUT"),
+		output("changed", changed)+
+			output("readme-path", readmePath)+
+			output("diff", diff))
+	if err != nil {
+		log.Printf("Failed setting outputs: %s", err)
+	}
+	err = appendFile(ciEnv("GITHUB_STEP_SUMMARY"), summary(ctx, diffs))
+	if err != nil {
+		log.Printf("Failed writing job summary: %s", err)
+	}
+}
+
+// Format a step output, with the multiline syntax.
+func output(name, value string) string {
+	delim := "GOREADME_EOF"
+	for strings.Contains(value, delim) {
+		delim += "_"
+	}
+	return fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delim, value, delim)
+}
+
+// Markdown job summary with the changed sections and the diff of every changed readme file.
+func summary(ctx context.Context, diffs []fileDiff) string {
+	var b strings.Builder
+	b.WriteString("## goreadme\n\n")
+	if len(diffs) == 0 {
+		b.WriteString("The readme files are up to date.\n")
+		return b.String()
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(&b, "### %s\n\n", d.path)
+		if sections := fileChangedSections(ctx, d.path); len(sections) > 0 {
+			fmt.Fprintf(&b, "Changed sections: %s\n\n", strings.Join(sections, ", "))
+		}
+		fmt.Fprintf(&b, "```diff\n%s\n```\n\n", d.diff)
+	}
+	return b.String()
+}
+
+// The sections of a readme file that were changed from the committed version.
+func fileChangedSections(ctx context.Context, file string) []string {
+	after, err := os.ReadFile(file)
+	if err != nil {
+		log.Printf("Failed reading %s: %s", file, err)
+		return nil
+	}
+	rel := file
+	if filepath.IsAbs(rel) {
+		if wd, err := os.Getwd(); err == nil {
+			if r, err := filepath.Rel(wd, file); err == nil {
+				rel = r
+			}
+		}
+	}
+	// The file may be new, in which case all its sections were changed.
+	before, _ := exec.CommandContext(ctx, "git", "show", "HEAD:./"+filepath.ToSlash(rel)).Output()
+	return changedSections(before, after)
+}
+
+// Append content to a file, if the file name is given.
+func appendFile(name, content string) error {
+	if name == "" {
+		return nil
+	}
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
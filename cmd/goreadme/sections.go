@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingRx = regexp.MustCompile(`^(#{1,6}) +(.+?) *#*$`)
+	linkRx    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+)
+
+// changedSections returns the titles of the sections that differ between two versions of a README
+// file, including sections that were added or removed. A section starts at a second level heading,
+// and the first section, which contains the title, the badges and the package doc, is titled by
+// the first level heading. Sections with the same title, such as a "Types" heading in the package
+// doc and the generated "Types" section, are told apart by their order, and the title of each
+// repeated section is suffixed with its number, such as "Types (2)".
+func changedSections(before, after []byte) []string {
+	oldSections := sections(before)
+	oldContent := make(map[string]string, len(oldSections))
+	for _, s := range oldSections {
+		oldContent[s.key] = s.content
+	}
+
+	var changed []string
+	seen := make(map[string]bool)
+	for _, s := range sections(after) {
+		seen[s.key] = true
+		if content, ok := oldContent[s.key]; !ok || content != s.content {
+			changed = append(changed, s.key)
+		}
+	}
+	for _, s := range oldSections {
+		if !seen[s.key] {
+			changed = append(changed, s.key)
+		}
+	}
+	return changed
+}
+
+type section struct {
+	title   string
+	content string
+	// key is the title, suffixed with the number of the section among the sections with the same
+	// title, if it is not the first.
+	key string
+}
+
+// sections splits a README file to its sections.
+func sections(text []byte) []section {
+	var (
+		ss      []section
+		cur     section
+		content strings.Builder
+		code    bool
+	)
+	s := bufio.NewScanner(bytes.NewReader(text))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "```") {
+			code = !code
+		}
+		if m := headingRx.FindStringSubmatch(line); !code && m != nil {
+			title := linkRx.ReplaceAllString(m[2], "$1")
+			switch {
+			case len(m[1]) == 1 && cur.title == "":
+				cur.title = title
+			case len(m[1]) == 2:
+				cur.content = content.String()
+				ss = append(ss, cur)
+				cur = section{title: title}
+				content.Reset()
+			}
+		}
+		content.WriteString(line + "\n")
+	}
+	cur.content = content.String()
+	ss = append(ss, cur)
+	if first := ss[0]; first.title == "" && strings.TrimSpace(first.content) == "" {
+		ss = ss[1:]
+	}
+	count := make(map[string]int)
+	for i := range ss {
+		count[ss[i].title]++
+		ss[i].key = ss[i].title
+		if n := count[ss[i].title]; n > 1 {
+			ss[i].key = fmt.Sprintf("%s (%d)", ss[i].title, n)
+		}
+	}
+	return ss
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedSections(t *testing.T) {
+	t.Parallel()
+
+	const old = "# pkg\n\nDoc.\n\n## Functions\n\n### func [A](/a.go#L1)\n\n## Types\n\n```go\n## Not a heading\n```\n\n## Examples\n\nExample.\n"
+	tests := []struct {
+		name string
+		new  string
+		want []string
+	}{
+		{
+			name: "same",
+			new:  old,
+		},
+		{
+			name: "doc",
+			new:  "# pkg\n\nChanged doc.\n\n## Functions\n\n### func [A](/a.go#L1)\n\n## Types\n\n```go\n## Not a heading\n```\n\n## Examples\n\nExample.\n",
+			want: []string{"pkg"},
+		},
+		{
+			name: "sub heading and code",
+			new:  "# pkg\n\nDoc.\n\n## Functions\n\n### func [A](/a.go#L2)\n\n## Types\n\n```go\n## Changed\n```\n\n## Examples\n\nExample.\n",
+			want: []string{"Functions", "Types"},
+		},
+		{
+			name: "added and removed",
+			new:  "# pkg\n\nDoc.\n\n## Functions\n\n### func [A](/a.go#L1)\n\n## Types\n\n```go\n## Not a heading\n```\n\n## Sub Packages\n\n* sub\n",
+			want: []string{"Sub Packages", "Examples"},
+		},
+		{
+			name: "repeated title",
+			new:  "# pkg\n\nDoc.\n\n## Functions\n\n### func [A](/a.go#L1)\n\n## Types\n\n```go\n## Not a heading\n```\n\n## Types\n\n### type [T](/a.go#L3)\n\n## Examples\n\nExample.\n",
+			want: []string{"Types (2)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, changedSections([]byte(old), []byte(tt.new)))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package goreadme
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// configFileNames are the names of the goreadme configuration files, by order of precedence
+// within a single directory.
+var configFileNames = []string{".goreadme.json", ".goreadme.yaml"}
+
+// FindConfig returns the path of the configuration file closest to dir. It looks for one of the
+// configFileNames in dir and in its parent directories, up to the module root, which is the first
+// directory that contains a go.mod file. It returns an empty path if no configuration file was
+// found.
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			} else if !os.IsNotExist(err) {
+				return "", err
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads a JSON or YAML configuration file into cfg, according to the file extension.
+// The file uses the JSON field names of Config. Fields that do not appear in the file keep their
+// values in cfg. A relative template_dir in the file is relative to the file directory.
+func LoadConfig(path string, cfg *Config) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var unmarshal func([]byte, interface{}) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		unmarshal = json.Unmarshal
+	case ".yaml", ".yml":
+		unmarshal = func(b []byte, v interface{}) error { return yaml.Unmarshal(b, v) }
+	default:
+		return errors.Errorf("unsupported config file extension %q", ext)
+	}
+	var paths struct {
+		TemplateDir string `json:"template_dir"`
+	}
+	if err := unmarshal(b, cfg); err != nil {
+		return errors.Wrapf(err, "failed parsing config file %s", path)
+	}
+	if err := unmarshal(b, &paths); err != nil {
+		return errors.Wrapf(err, "failed parsing config file %s", path)
+	}
+	if dir := paths.TemplateDir; dir != "" && !filepath.IsAbs(dir) {
+		cfg.TemplateDir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return nil
+}
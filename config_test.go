@@ -0,0 +1,87 @@
+package goreadme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindConfig(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mod := filepath.Join(root, "mod")
+	writeFile(t, filepath.Join(root, ".goreadme.json"), `{}`)
+	writeFile(t, filepath.Join(mod, "go.mod"), "module example.com/mod\n")
+	writeFile(t, filepath.Join(mod, ".goreadme.yaml"), "types: true\n")
+	writeFile(t, filepath.Join(mod, "a", ".goreadme.json"), `{"functions": true}`)
+	writeFile(t, filepath.Join(mod, "a", "b", "b.go"), "package b\n")
+	writeFile(t, filepath.Join(mod, "c", "c.go"), "package c\n")
+	writeFile(t, filepath.Join(root, "nomod", "d", "d.go"), "package d\n")
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{dir: mod, want: filepath.Join(mod, ".goreadme.yaml")},
+		{dir: filepath.Join(mod, "a"), want: filepath.Join(mod, "a", ".goreadme.json")},
+		{dir: filepath.Join(mod, "a", "b"), want: filepath.Join(mod, "a", ".goreadme.json")},
+		{dir: filepath.Join(mod, "c"), want: filepath.Join(mod, ".goreadme.yaml")},
+		{dir: filepath.Join(root, "nomod", "d"), want: filepath.Join(root, ".goreadme.json")},
+	}
+
+	for _, tt := range tests {
+		got, err := FindConfig(tt.dir)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.dir)
+	}
+
+	// The search stops at the module root.
+	require.NoError(t, os.Remove(filepath.Join(mod, ".goreadme.yaml")))
+	got, err := FindConfig(filepath.Join(mod, "c"))
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, ".goreadme.yaml")
+	writeFile(t, yamlFile, "title: Title\ntypes: true\nbadges:\n  go_doc: true\n")
+	jsonFile := filepath.Join(dir, ".goreadme.json")
+	writeFile(t, jsonFile, `{"title": "Title", "types": true, "badges": {"go_doc": true}}`)
+
+	for _, path := range []string{yamlFile, jsonFile} {
+		cfg := Config{Credit: true, Functions: true}
+		require.NoError(t, LoadConfig(path, &cfg))
+
+		want := Config{Title: "Title", Types: true, Credit: true, Functions: true}
+		want.Badges.GoDoc = true
+		assert.Equal(t, want, cfg, path)
+	}
+
+	// A relative template directory is relative to the config file directory.
+	templatesFile := filepath.Join(dir, "sub", ".goreadme.yaml")
+	writeFile(t, templatesFile, "template_dir: templates\n")
+	cfg := Config{TemplateDir: "other"}
+	require.NoError(t, LoadConfig(templatesFile, &cfg))
+	assert.Equal(t, filepath.Join(dir, "sub", "templates"), cfg.TemplateDir)
+
+	absFile := filepath.Join(dir, "abs.json")
+	writeFile(t, absFile, `{"template_dir": "/templates"}`)
+	require.NoError(t, LoadConfig(absFile, &cfg))
+	assert.Equal(t, "/templates", cfg.TemplateDir)
+
+	writeFile(t, filepath.Join(dir, "invalid.json"), `{"types": "yes"}`)
+	assert.Error(t, LoadConfig(filepath.Join(dir, "invalid.json"), &Config{}))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0775))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0664))
+}
@@ -0,0 +1,227 @@
+package goreadme
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"regexp"
+
+	"github.com/golang/gddo/doc"
+	"github.com/posener/goreadme/internal/markdown"
+)
+
+// Modes of rendering deprecated identifiers, for Config.Deprecated.
+const (
+	// DeprecatedHide omits deprecated identifiers from the README.
+	DeprecatedHide = "hide"
+	// DeprecatedSection moves deprecated identifiers to a Deprecated section.
+	DeprecatedSection = "section"
+	// DeprecatedMark adds a deprecated label and the replacement advice to deprecated identifiers.
+	DeprecatedMark = "mark"
+)
+
+// deprecatedDecls are the deprecated identifiers of a package that are rendered in the Deprecated
+// section.
+type deprecatedDecls struct {
+	Consts  []*doc.Value
+	Vars    []*doc.Value
+	Funcs   []*doc.Func
+	Types   []*doc.Type
+	Methods []*doc.Func
+	// Fields are the deprecated struct fields and interface methods of types that are not
+	// deprecated, as declarations of their types with only these members.
+	Fields []*doc.Value
+}
+
+func (d *deprecatedDecls) empty() bool {
+	return len(d.Consts)+len(d.Vars)+len(d.Funcs)+len(d.Types)+len(d.Methods)+len(d.Fields) == 0
+}
+
+// isDeprecated returns true if the doc has a deprecation paragraph.
+func isDeprecated(doc string) bool {
+	_, _, ok := markdown.SplitDeprecated(doc)
+	return ok
+}
+
+// removeDeprecated removes the deprecated identifiers from the package in the hide and section
+// modes. In the section mode, it returns the removed identifiers of the sections that are
+// rendered with the config, or nil if there are none.
+func removeDeprecated(p *doc.Package, cfg Config) *deprecatedDecls {
+	if cfg.Deprecated != DeprecatedHide && cfg.Deprecated != DeprecatedSection {
+		return nil
+	}
+	d := &deprecatedDecls{}
+	// keep adds the removed identifiers to the section only if their section is rendered.
+	keep := func(ok bool, dst *[]*doc.Value, removed []*doc.Value) {
+		if ok {
+			*dst = append(*dst, removed...)
+		}
+	}
+	keepFuncs := func(ok bool, dst *[]*doc.Func, removed []*doc.Func) {
+		if ok {
+			*dst = append(*dst, removed...)
+		}
+	}
+
+	var removedValues []*doc.Value
+	var removedFuncs []*doc.Func
+	p.Consts, removedValues = splitValues(p.Consts)
+	keep(cfg.Consts, &d.Consts, removedValues)
+	p.Vars, removedValues = splitValues(p.Vars)
+	keep(cfg.Vars, &d.Vars, removedValues)
+	p.Funcs, removedFuncs = splitFuncs(p.Funcs)
+	keepFuncs(cfg.Functions, &d.Funcs, removedFuncs)
+
+	var types []*doc.Type
+	for _, t := range p.Types {
+		if isDeprecated(t.Doc) {
+			if cfg.Types {
+				d.Types = append(d.Types, t)
+			}
+			continue
+		}
+		t := *t
+		t.Consts, removedValues = splitValues(t.Consts)
+		keep(cfg.Types && cfg.Consts, &d.Consts, removedValues)
+		t.Vars, removedValues = splitValues(t.Vars)
+		keep(cfg.Types && cfg.Vars, &d.Vars, removedValues)
+		t.Funcs, removedFuncs = splitFuncs(t.Funcs)
+		keepFuncs(cfg.Types && cfg.Factories, &d.Funcs, removedFuncs)
+		t.Methods, removedFuncs = splitFuncs(t.Methods)
+		keepFuncs(cfg.Types && cfg.Methods, &d.Methods, removedFuncs)
+		if kept, removed, ok := splitDecl(t.Decl.Text); ok {
+			t.Decl = doc.Code{Text: kept}
+			if cfg.Types && cfg.RenderTypeContent {
+				d.Fields = append(d.Fields, &doc.Value{Decl: doc.Code{Text: removed}, Pos: t.Pos})
+			}
+		}
+		types = append(types, &t)
+	}
+	p.Types = types
+
+	if cfg.Deprecated != DeprecatedSection || d.empty() {
+		return nil
+	}
+	return d
+}
+
+// splitValues splits the deprecated const or var declarations, and the deprecated specs of the
+// declarations that are not deprecated, from the values.
+func splitValues(values []*doc.Value) (kept, removed []*doc.Value) {
+	for _, v := range values {
+		if isDeprecated(v.Doc) {
+			removed = append(removed, v)
+			continue
+		}
+		keptText, removedText, ok := splitDecl(v.Decl.Text)
+		if !ok {
+			kept = append(kept, v)
+			continue
+		}
+		if keptText != "" {
+			kept = append(kept, &doc.Value{Decl: doc.Code{Text: keptText}, Pos: v.Pos, Doc: v.Doc})
+		}
+		removed = append(removed, &doc.Value{Decl: doc.Code{Text: removedText}, Pos: v.Pos})
+	}
+	return kept, removed
+}
+
+func splitFuncs(funcs []*doc.Func) (kept, removed []*doc.Func) {
+	for _, f := range funcs {
+		if isDeprecated(f.Doc) {
+			removed = append(removed, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, removed
+}
+
+var openingBlankLine = regexp.MustCompile(`([({])\n\n+`)
+
+// splitDecl splits the deprecated specs of a const or var declaration, or the deprecated fields
+// or methods of a struct or interface type declaration, to a declaration of their own. It returns
+// the declaration without them, which is empty if all were deprecated, and the declaration with
+// only them. ok is false if nothing is deprecated. Constant declarations that repeat implicit
+// values are not split, as it would change the meaning of the remaining constants.
+func splitDecl(text string) (kept, removed string, ok bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n"+text, parser.ParseComments)
+	if err != nil || len(f.Decls) != 1 {
+		return text, "", false
+	}
+	decl, isGen := f.Decls[0].(*ast.GenDecl)
+	if !isGen {
+		return text, "", false
+	}
+	cmap := ast.NewCommentMap(fset, f, f.Comments)
+	format := func(node ast.Node) string {
+		var b bytes.Buffer
+		n := &printer.CommentedNode{Node: node, Comments: cmap.Filter(node).Comments()}
+		if err := (&printer.Config{Mode: printer.UseSpaces, Tabwidth: 4}).Fprint(&b, fset, n); err != nil {
+			return ""
+		}
+		// Removed specs or fields leave an empty line after the opening parenthesis or brace.
+		return openingBlankLine.ReplaceAllString(b.String(), "$1\n")
+	}
+
+	if decl.Tok == token.TYPE {
+		if len(decl.Specs) != 1 {
+			return text, "", false
+		}
+		spec := decl.Specs[0].(*ast.TypeSpec)
+		var fields *ast.FieldList
+		switch t := spec.Type.(type) {
+		case *ast.StructType:
+			fields = t.Fields
+		case *ast.InterfaceType:
+			fields = t.Methods
+		default:
+			return text, "", false
+		}
+		keptFields, removedFields := splitFields(fields.List)
+		if len(removedFields) == 0 {
+			return text, "", false
+		}
+		fields.List = removedFields
+		removed = format(decl)
+		fields.List = keptFields
+		return format(decl), removed, true
+	}
+
+	var keptSpecs, removedSpecs []ast.Spec
+	for _, s := range decl.Specs {
+		vs := s.(*ast.ValueSpec)
+		if decl.Tok == token.CONST && vs.Type == nil && len(vs.Values) == 0 {
+			return text, "", false
+		}
+		if isDeprecated(vs.Doc.Text()) || isDeprecated(vs.Comment.Text()) {
+			removedSpecs = append(removedSpecs, s)
+		} else {
+			keptSpecs = append(keptSpecs, s)
+		}
+	}
+	if len(removedSpecs) == 0 {
+		return text, "", false
+	}
+	decl.Specs = removedSpecs
+	removed = format(decl)
+	if len(keptSpecs) > 0 {
+		decl.Specs = keptSpecs
+		kept = format(decl)
+	}
+	return kept, removed, true
+}
+
+func splitFields(fields []*ast.Field) (kept, removed []*ast.Field) {
+	for _, f := range fields {
+		if isDeprecated(f.Doc.Text()) || isDeprecated(f.Comment.Text()) {
+			removed = append(removed, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, removed
+}
@@ -0,0 +1,125 @@
+package goreadme
+
+import (
+	"testing"
+
+	"github.com/golang/gddo/doc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDecl(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		text        string
+		wantKept    string
+		wantRemoved string
+		wantOK      bool
+	}{
+		{
+			name: "not deprecated",
+			text: "var (\n\tA = 1\n\tB = 2\n)",
+		},
+		{
+			// Like the declarations of gddo, the printer pads aligned cells to the tab width.
+			name:        "var specs",
+			text:        "var (\n\t// A is a.\n\tA = 1\n\t// B is b.\n\t//\n\t// Deprecated: Use A.\n\tB = 2\n\tC = 3 // Deprecated: Use A.\n)",
+			wantKept:    "var (\n    // A is a.\n    A = 1\n)",
+			wantRemoved: "var (\n    // B is b.\n    //\n    // Deprecated: Use A.\n    B   = 2\n    C   = 3 // Deprecated: Use A.\n)",
+			wantOK:      true,
+		},
+		{
+			name:        "deprecated paragraph is not last",
+			text:        "var (\n\tA = 1\n\t// B is b.\n\t//\n\t// Deprecated: Use A.\n\t//\n\t// B is 2.\n\tB = 2\n)",
+			wantKept:    "var (\n    A = 1\n)",
+			wantRemoved: "var (\n    // B is b.\n    //\n    // Deprecated: Use A.\n    //\n    // B is 2.\n    B = 2\n)",
+			wantOK:      true,
+		},
+		{
+			name:        "all specs",
+			text:        "const (\n\t// Deprecated: Use C.\n\tA = 1\n\t// Deprecated: Use C.\n\tB = 2\n)",
+			wantRemoved: "const (\n    // Deprecated: Use C.\n    A   = 1\n    // Deprecated: Use C.\n    B   = 2\n)",
+			wantOK:      true,
+		},
+		{
+			name:        "windows line endings",
+			text:        "var (\r\n\tA = 1\r\n\t// Deprecated: Use A.\r\n\tB = 2\r\n)",
+			wantKept:    "var (\n    A = 1\n)",
+			wantRemoved: "var (\n    // Deprecated: Use A.\n    B = 2\n)",
+			wantOK:      true,
+		},
+		{
+			name:        "explicit const values",
+			text:        "const (\n\tA int = iota\n\t// Deprecated: Use A.\n\tB int = iota\n)",
+			wantKept:    "const (\n    A int = iota\n)",
+			wantRemoved: "const (\n    // Deprecated: Use A.\n    B int = iota\n)",
+			wantOK:      true,
+		},
+		{
+			// Splitting implicit values would change the values of the following constants.
+			name: "implicit const values",
+			text: "const (\n\tA = iota\n\t// Deprecated: Use A.\n\tB\n\tC\n)",
+		},
+		{
+			name:        "struct fields",
+			text:        "type T struct {\n\t// A is a.\n\tA int\n\t// Deprecated: Use A.\n\tB int\n\tC, D string // Deprecated: Use A.\n}",
+			wantKept:    "type T struct {\n    // A is a.\n    A int\n}",
+			wantRemoved: "type T struct {\n    // Deprecated: Use A.\n    B    int\n    C, D string // Deprecated: Use A.\n}",
+			wantOK:      true,
+		},
+		{
+			name:        "interface methods",
+			text:        "type I interface {\n\tA()\n\t// Deprecated: Use A.\n\tB()\n}",
+			wantKept:    "type I interface {\n    A()\n}",
+			wantRemoved: "type I interface {\n    // Deprecated: Use A.\n    B()\n}",
+			wantOK:      true,
+		},
+		{
+			name: "other type",
+			text: "type F func()",
+		},
+		{
+			name: "invalid",
+			text: "var (",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			kept, removed, ok := splitDecl(tt.text)
+			assert.Equal(t, tt.wantOK, ok)
+			if !tt.wantOK {
+				assert.Equal(t, tt.text, kept)
+				assert.Empty(t, removed)
+				return
+			}
+			assert.Equal(t, tt.wantKept, kept)
+			assert.Equal(t, tt.wantRemoved, removed)
+		})
+	}
+}
+
+func TestSplitValues(t *testing.T) {
+	t.Parallel()
+
+	notDeprecated := &doc.Value{Decl: doc.Code{Text: "var A = 1"}, Doc: "A is a.\n"}
+	deprecated := &doc.Value{Decl: doc.Code{Text: "var B = 2"}, Doc: "B is b.\n\nDeprecated: Use A.\n"}
+	partly := &doc.Value{Decl: doc.Code{Text: "var (\n\tC = 3\n\t// Deprecated: Use C.\n\tD = 4\n)"}, Doc: "C and D.\n", Pos: doc.Pos{Line: 7}}
+	implicit := &doc.Value{Decl: doc.Code{Text: "const (\n\tE = iota\n\t// Deprecated: Use E.\n\tF\n)"}}
+
+	kept, removed := splitValues([]*doc.Value{notDeprecated, deprecated, partly, implicit})
+
+	require.Len(t, kept, 3)
+	assert.Same(t, notDeprecated, kept[0])
+	assert.Equal(t, &doc.Value{Decl: doc.Code{Text: "var (\n    C = 3\n)"}, Doc: "C and D.\n", Pos: doc.Pos{Line: 7}}, kept[1])
+	// Constants with implicit values are kept as is.
+	assert.Same(t, implicit, kept[2])
+
+	require.Len(t, removed, 2)
+	assert.Same(t, deprecated, removed[0])
+	// The doc of the declaration describes the kept specs.
+	assert.Equal(t, &doc.Value{Decl: doc.Code{Text: "var (\n    // Deprecated: Use C.\n    D = 4\n)"}, Pos: doc.Pos{Line: 7}}, removed[1])
+}
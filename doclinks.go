@@ -0,0 +1,264 @@
+package goreadme
+
+import (
+	"go/ast"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/golang/gddo/doc"
+	"github.com/posener/goreadme/internal/markdown"
+	"github.com/posener/goreadme/internal/template"
+)
+
+const defaultGoDocURL = "https://pkg.go.dev"
+
+// markdownOptions returns the options for converting the doc comments of the package to markdown,
+// in the given template.
+func (r *GoReadme) markdownOptions(pkg *pkg, tmpl string) []markdown.Option {
+	p := pkg.Package
+	var links map[string]string
+	if tmpl == template.Main && r.config.APIFile != "" {
+		// Sections of the API are linked in the API reference file.
+		links = identifierLinks(p, pkg.Deprecated, apiConfig(r.config), r.config.APIFile)
+	} else {
+		links = identifierLinks(p, pkg.Deprecated, r.config, "")
+	}
+	options := []markdown.Option{
+		markdown.OptNoDiff(r.config.NoDiffBlocks),
+		markdown.OptUseStdlib(r.config.StdMarkdown),
+		markdown.OptWords(links),
+	}
+	if r.config.SourceURLPattern != "" {
+		if s, ok := newSourceURLs(p, r.config); ok {
+			options = append(options, markdown.OptLocalLinkURL(s.local))
+		}
+	}
+	if !r.config.StdMarkdown {
+		return options
+	}
+	goDocURL := r.config.GoDocURL
+	if goDocURL == "" {
+		goDocURL = defaultGoDocURL
+	}
+	return append(options,
+		// Deprecated identifiers are still declared, even if they are not rendered.
+		markdown.OptLookupSym(lookupSym(pkg.all)),
+		// The package name may have been replaced by the title or the command name.
+		markdown.OptLookupPackage(lookupPackage(pkg.all.Name, pkg.imports)),
+		markdown.OptDocLinkURL(func(l *comment.DocLink) string {
+			// Doc links to the package symbols link to their sections, if they are rendered.
+			if l.ImportPath == "" {
+				name := l.Name
+				if l.Recv != "" {
+					name = l.Recv + "." + name
+				}
+				if link, ok := links[name]; ok {
+					return link
+				}
+			}
+			l2 := *l
+			if l2.ImportPath == "" {
+				l2.ImportPath = p.ImportPath
+			}
+			return l2.DefaultURL(strings.TrimSuffix(goDocURL, "/"))
+		}),
+	)
+}
+
+// identifierLinks returns links from the exported identifiers of the package to their sections,
+// for the sections that are rendered with the config, including the identifiers of the deprecated
+// section, if given. Fields and methods of types, such as `Type.Name`, link to the method section
+// if there is one, and otherwise to the type section. The links are relative to the given file,
+// or to the current file if it is empty.
+func identifierLinks(p *doc.Package, deprecated *deprecatedDecls, cfg Config, file string) map[string]string {
+	links := make(map[string]string)
+	add := func(name, heading, doc string) {
+		// Marked headings have a deprecated label.
+		if cfg.Deprecated == DeprecatedMark && isDeprecated(doc) {
+			heading += " (deprecated)"
+		}
+		if token.IsExported(strings.TrimPrefix(name[strings.LastIndexByte(name, '.')+1:], "*")) {
+			links[name] = file + "#" + slug(heading)
+		}
+	}
+	addFunc := func(f *doc.Func) {
+		add(f.Name, "func "+f.Name, f.Doc)
+	}
+	addMethod := func(typeName string, m *doc.Func) {
+		add(typeName+"."+m.Name, "func ("+m.Recv+") "+m.Name, m.Doc)
+	}
+	addType := func(t *doc.Type) {
+		if !token.IsExported(t.Name) {
+			return
+		}
+		add(t.Name, "type "+t.Name, t.Doc)
+		for _, name := range memberNames(t.Decl.Text) {
+			add(t.Name+"."+name, "type "+t.Name, t.Doc)
+		}
+		if cfg.Factories {
+			for _, f := range t.Funcs {
+				addFunc(f)
+			}
+		}
+		if cfg.Methods {
+			for _, m := range t.Methods {
+				addMethod(t.Name, m)
+			}
+		}
+	}
+
+	if cfg.Functions {
+		for _, f := range p.Funcs {
+			addFunc(f)
+		}
+	}
+	if cfg.Types {
+		for _, t := range p.Types {
+			addType(t)
+		}
+	}
+	if deprecated != nil {
+		// The deprecated identifiers are only collected for the sections that are rendered.
+		for _, f := range deprecated.Funcs {
+			addFunc(f)
+		}
+		for _, t := range deprecated.Types {
+			addType(t)
+		}
+		for _, m := range deprecated.Methods {
+			addMethod(recvType(m.Recv), m)
+		}
+	}
+	return links
+}
+
+// recvType returns the type name of a method receiver, such as `T` for `*T[K]`.
+func recvType(recv string) string {
+	recv = strings.TrimPrefix(recv, "*")
+	if i := strings.IndexByte(recv, '['); i >= 0 {
+		recv = recv[:i]
+	}
+	return recv
+}
+
+// memberNames returns the names of the fields of a struct type declaration, or the names of the
+// methods of an interface type declaration.
+func memberNames(decl string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+decl, 0)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		var fields *ast.FieldList
+		switch n := n.(type) {
+		case *ast.StructType:
+			fields = n.Fields
+		case *ast.InterfaceType:
+			fields = n.Methods
+		default:
+			return true
+		}
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+		}
+		// Don't collect members of nested types.
+		return false
+	})
+	return names
+}
+
+// lookupSym returns a function that reports whether a symbol is declared in the package.
+func lookupSym(p *doc.Package) func(recv, name string) bool {
+	syms := make(map[[2]string]bool)
+	addValues := func(values []*doc.Value) {
+		for _, v := range values {
+			for _, name := range declNames(v.Decl.Text) {
+				syms[[2]string{"", name}] = true
+			}
+		}
+	}
+	addValues(p.Consts)
+	addValues(p.Vars)
+	for _, f := range p.Funcs {
+		syms[[2]string{"", f.Name}] = true
+	}
+	for _, t := range p.Types {
+		syms[[2]string{"", t.Name}] = true
+		addValues(t.Consts)
+		addValues(t.Vars)
+		for _, f := range t.Funcs {
+			syms[[2]string{"", f.Name}] = true
+		}
+		for _, m := range t.Methods {
+			syms[[2]string{t.Name, m.Name}] = true
+		}
+	}
+	return func(recv, name string) bool {
+		return syms[[2]string{recv, name}]
+	}
+}
+
+// lookupPackage returns a function that resolves the name of the package, as declared in its
+// source, and the names of its imports.
+func lookupPackage(name string, imports map[string]string) func(name string) (string, bool) {
+	return func(n string) (string, bool) {
+		if n == name {
+			return "", true
+		}
+		imp, ok := imports[n]
+		return imp, ok
+	}
+}
+
+// guessImportNames returns the import paths by the default names of the imported packages, which
+// are guessed from the import paths. It is used when the source files are not available.
+func guessImportNames(importPaths []string) map[string]string {
+	names := make(map[string]string)
+	for _, imp := range importPaths {
+		names[importName(imp)] = imp
+	}
+	return names
+}
+
+// gopkgVersionRx matches the version suffix of gopkg.in import paths, such as `.v2`.
+var gopkgVersionRx = regexp.MustCompile(`\.v[0-9]+$`)
+
+// importName guesses the default name of an imported package, skipping a major version suffix.
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = gopkgVersionRx.ReplaceAllString(name, "")
+	return strings.TrimPrefix(name, "go-")
+}
+
+// declNames returns the names that are declared in a const or var declaration.
+func declNames(decl string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+decl, 0)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, s := range gd.Specs {
+			if vs, ok := s.(*ast.ValueSpec); ok {
+				for _, n := range vs.Names {
+					names = append(names, n.Name)
+				}
+			}
+		}
+	}
+	return names
+}
@@ -2,12 +2,10 @@ package goreadme
 
 import (
 	"context"
-	"net/http"
 	"path/filepath"
 	"sort"
 	"sync"
 
-	"github.com/golang/gddo/doc"
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 )
@@ -20,7 +18,7 @@ var skipDirs = map[string]bool{
 
 // subpackagesFetcher fetches sub packages recursively.
 type subpackagesFetcher struct {
-	client     *http.Client
+	source     Source
 	importPath string
 	recursive  bool
 
@@ -30,8 +28,12 @@ type subpackagesFetcher struct {
 	packages []subPkg
 }
 
-func (f *subpackagesFetcher) Fetch(ctx context.Context, pkg *doc.Package) ([]subPkg, error) {
-	for _, subDir := range pkg.Subdirectories {
+func (f *subpackagesFetcher) Fetch(ctx context.Context) ([]subPkg, error) {
+	subDirs, err := f.source.Subdirectories(ctx, f.importPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed listing %s", f.importPath)
+	}
+	for _, subDir := range subDirs {
 		f.fetch(ctx, subDir)
 	}
 	f.wg.Wait()
@@ -50,7 +52,11 @@ func (f *subpackagesFetcher) fetch(ctx context.Context, subDir string) {
 
 	go func() {
 		defer f.wg.Done()
-		sp, err := docGet(ctx, f.client, importPath, "")
+		sp, err := f.source.Package(ctx, importPath)
+		var subDirs []string
+		if err == nil && f.recursive {
+			subDirs, err = f.source.Subdirectories(ctx, importPath)
+		}
 		f.mu.Lock()
 		defer f.mu.Unlock()
 		if err != nil {
@@ -61,10 +67,8 @@ func (f *subpackagesFetcher) fetch(ctx context.Context, subDir string) {
 		if sp.Name != "" {
 			f.packages = append(f.packages, subPkg{Path: subDir, Package: sp})
 		}
-		if f.recursive {
-			for _, sd := range sp.Subdirectories {
-				f.fetch(ctx, subDir+"/"+sd)
-			}
+		for _, sd := range subDirs {
+			f.fetch(ctx, subDir+"/"+sd)
 		}
 	}()
 }
@@ -0,0 +1,187 @@
+package goreadme
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/golang/gddo/doc"
+	"github.com/pkg/errors"
+)
+
+// flagInfo is a command line flag of a command, that is defined with the standard library flag
+// package.
+type flagInfo struct {
+	Name    string
+	Type    string
+	Default string
+	Usage   string
+}
+
+// flagFuncs maps the functions of the flag package to the type of the flag, and to the index of
+// the name argument. The default value, if any, follows the name argument, and the usage follows
+// the default value or the name argument. The Func and BoolFunc functions have a function after
+// the usage.
+var flagFuncs = map[string]struct {
+	typ     string
+	nameArg int
+	hasDef  bool
+}{
+	"Bool":        {"bool", 0, true},
+	"BoolVar":     {"bool", 1, true},
+	"Duration":    {"duration", 0, true},
+	"DurationVar": {"duration", 1, true},
+	"Float64":     {"float64", 0, true},
+	"Float64Var":  {"float64", 1, true},
+	"Int":         {"int", 0, true},
+	"IntVar":      {"int", 1, true},
+	"Int64":       {"int64", 0, true},
+	"Int64Var":    {"int64", 1, true},
+	"String":      {"string", 0, true},
+	"StringVar":   {"string", 1, true},
+	"Uint":        {"uint", 0, true},
+	"UintVar":     {"uint", 1, true},
+	"Uint64":      {"uint64", 0, true},
+	"Uint64Var":   {"uint64", 1, true},
+	"Var":         {"value", 1, false},
+	"TextVar":     {"value", 1, true},
+	"Func":        {"value", 0, false},
+	"BoolFunc":    {"bool", 0, false},
+}
+
+// commandFlags returns the flags that are defined with the flag package in the source files of the
+// command in dir, in the order of their definition.
+func commandFlags(p *doc.Package, dir string) ([]flagInfo, error) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, file := range p.Files {
+		path := filepath.Join(dir, file.Name)
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed parsing %s", path)
+		}
+		files = append(files, f)
+	}
+	consts := stringConsts(files)
+	name := func(e ast.Expr) string {
+		if id, ok := e.(*ast.Ident); ok {
+			if v, ok := consts[id.Name]; ok {
+				return v
+			}
+		}
+		return exprString(fset, e)
+	}
+
+	var flags []flagInfo
+	for _, f := range files {
+		flagPkg := fileImportName(f, "flag")
+		if flagPkg == "" {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != flagPkg {
+				return true
+			}
+			fn, ok := flagFuncs[sel.Sel.Name]
+			if !ok {
+				return true
+			}
+			usageArg := fn.nameArg + 1
+			if fn.hasDef {
+				usageArg++
+			}
+			if len(call.Args) <= usageArg {
+				return true
+			}
+			fl := flagInfo{
+				Name:  name(call.Args[fn.nameArg]),
+				Type:  fn.typ,
+				Usage: exprString(fset, call.Args[usageArg]),
+			}
+			if fn.hasDef {
+				// An empty string default is rendered as no default.
+				if def := exprSource(fset, call.Args[fn.nameArg+1]); def != `""` && def != "``" {
+					fl.Default = def
+				}
+			}
+			flags = append(flags, fl)
+			return true
+		})
+	}
+	return flags, nil
+}
+
+// stringConsts returns the values of the string constants that are declared in the files.
+func stringConsts(files []*ast.File) map[string]string {
+	consts := make(map[string]string)
+	for _, f := range files {
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, n := range vs.Names {
+					if i >= len(vs.Values) {
+						break
+					}
+					lit, ok := vs.Values[i].(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						continue
+					}
+					if v, err := strconv.Unquote(lit.Value); err == nil {
+						consts[n.Name] = v
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+// fileImportName returns the name of the imported package with the given path in the file, or an
+// empty string if it is not imported.
+func fileImportName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err != nil || p != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return filepath.Base(path)
+	}
+	return ""
+}
+
+// exprString returns the value of a string literal, or the source of any other expression.
+func exprString(fset *token.FileSet, e ast.Expr) string {
+	if lit, ok := e.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if s, err := strconv.Unquote(lit.Value); err == nil {
+			return s
+		}
+	}
+	return exprSource(fset, e)
+}
+
+func exprSource(fset *token.FileSet, e ast.Expr) string {
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, fset, e); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(b.String())
+}
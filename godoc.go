@@ -2,40 +2,111 @@ package goreadme
 
 import (
 	"context"
-	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 
 	"github.com/golang/gddo/doc"
-	"github.com/pkg/errors"
 )
 
-// docGet is a wrapper around doc.Get function, that workarounds golang/gddo#600.
-func docGet(ctx context.Context, client *http.Client, name, tag string) (*doc.Package, error) {
-	p, err := doc.Get(ctx, client, name, tag)
+// defaultProxyURL is the Go module proxy that is used when GOPROXY does not set one.
+const defaultProxyURL = "https://proxy.golang.org"
+
+// defaultSource loads filesystem paths with LocalSource, and fetches import paths from their
+// version control service using gddo. If a ref is given, import paths are loaded from the Go
+// module proxy at this version instead, as gddo fetches only the default branch.
+type defaultSource struct {
+	client *http.Client
+	ref    string
+	proxy  *ProxySource
+
+	mu sync.Mutex
+	// fetched caches fetched packages, as gddo returns the sub directories together with the
+	// package documentation.
+	fetched map[string]*doc.Package
+}
+
+func (s *defaultSource) Package(ctx context.Context, name string) (*doc.Package, error) {
+	if isLocal(name) {
+		return LocalSource{}.Package(ctx, name)
+	}
+	if s.ref != "" {
+		return s.proxySource().Package(ctx, name)
+	}
+	s.mu.Lock()
+	p, ok := s.fetched[name]
+	s.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+	p, err := doc.Get(ctx, s.client, name, "")
 	if err != nil {
 		return nil, err
 	}
-	err = workaroundLocalSubdirs(p, name)
-	return p, err
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fetched == nil {
+		s.fetched = make(map[string]*doc.Package)
+	}
+	s.fetched[name] = p
+	return p, nil
 }
 
-// workaroundLocalSubdirs adds subdireoctires for local load.
-// Workaround for golang/gddo#600
-func workaroundLocalSubdirs(p *doc.Package, pkg string) error {
-	if !strings.HasPrefix(pkg, ".") {
-		return nil // Not local
+func (s *defaultSource) Dir(ctx context.Context, name string) (string, error) {
+	if isLocal(name) {
+		return name, nil
+	}
+	if s.ref != "" {
+		return s.proxySource().Dir(ctx, name)
 	}
+	// Source files of fetched packages are not available.
+	return "", nil
+}
 
-	files, err := ioutil.ReadDir(p.ImportPath)
+func (s *defaultSource) Subdirectories(ctx context.Context, name string) ([]string, error) {
+	if isLocal(name) {
+		return LocalSource{}.Subdirectories(ctx, name)
+	}
+	if s.ref != "" {
+		return s.proxySource().Subdirectories(ctx, name)
+	}
+	p, err := s.Package(ctx, name)
 	if err != nil {
-		errors.Wrap(err, "Failed reading import path")
+		return nil, err
+	}
+	return p.Subdirectories, nil
+}
+
+// Close removes the modules that were downloaded from the module proxy.
+func (s *defaultSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proxy == nil {
+		return nil
 	}
+	return s.proxy.Close()
+}
+
+func (s *defaultSource) proxySource() *ProxySource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proxy == nil {
+		client := s.client
+		if client == nil {
+			client = http.DefaultClient
+		}
+		s.proxy = NewProxySource(client, proxyURL(), s.ref)
+	}
+	return s.proxy
+}
 
-	for _, f := range files {
-		if f.IsDir() {
-			p.Subdirectories = append(p.Subdirectories, f.Name())
+// proxyURL returns the first module proxy URL in GOPROXY, or the default Go module proxy.
+func proxyURL() string {
+	for _, p := range strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' }) {
+		if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") {
+			return p
 		}
 	}
-	return nil
+	return defaultProxyURL
 }
@@ -9,7 +9,30 @@
 // action is trigerred, generates a new README file, and if there is a change - commits and pushes
 // it to the main branch. In pull requests that affect the README content, if the `GITHUB_TOKEN`
 // is given, the action will post a comment on the pull request with changes that will be made to
-// the README file.
+// the README file. Following runs edit the same comment, and when the changes go away, the
+// comment is updated, deleted or resolved according to the `pr-comment-clean` input.
+//
+// If the main branch is protected, set the `mode` input to `pull-request`. Instead of pushing the
+// changes, the action commits them to the `goreadme/update-readme` branch and opens a pull request,
+// or updates the open one. When the README is up to date, the open pull request is closed.
+//
+// The README can also be regenerated on schedule, workflow_dispatch and release events, for
+// example to refresh version badges. The `on-schedule`, `on-workflow-dispatch` and `on-release`
+// inputs choose what to do with the changes in each event: `push` them, open a `pull-request` or
+// `check` that there are none and fail otherwise. Schedule and workflow_dispatch events follow the
+// `mode` input by default, and release events open a pull request to the default branch, with the
+// README generated from the head of this branch:
+//
+//	on:
+//	  schedule:
+//	    - cron: '0 3 * * *'
+//	  workflow_dispatch:
+//	  release:
+//	    types: [published]
+//
+// The action sets the `changed`, `readme-path` and `diff` outputs, which can be used in following
+// workflow steps, and writes a job summary with the changed sections and the diff of each README
+// file. The outputs are not listed in ./action.yml, which is generated from the command flags.
 //
 // To use this with Github actions, add the following content to `.github/workflows/goreadme.yml`.
 // See ./action.yml for all available input options.
@@ -38,11 +61,41 @@
 //	            # Optional: Token allows goreadme to comment the PR with diff preview.
 //	            GITHUB_TOKEN: '${{ secrets.GITHUB_TOKEN }}'
 //
+// # GitLab CI
+//
+// The command line tool detects GitLab CI pipelines. In merge request pipelines, it posts the
+// changes that will be made to the README file as a merge request note, and in default branch
+// pipelines, it commits and pushes them. Like the Github pull request comment, the note is edited
+// in following pipelines instead of posting a new one. Both require a `GITLAB_TOKEN` variable
+// with a project access token with the api and write_repository scopes. The API URL defaults to
+// `CI_API_V4_URL` and can be changed with the `-gitlab-api-url` flag. Add the following job to
+// `.gitlab-ci.yml`:
+//
+//	goreadme:
+//	  image: golang
+//	  rules:
+//	    - if: $CI_PIPELINE_SOURCE == "merge_request_event"
+//	    - if: $CI_COMMIT_BRANCH == $CI_DEFAULT_BRANCH
+//	  script:
+//	    - go install github.com/posener/goreadme/cmd/goreadme@latest
+//	    - README_FILE=README.md goreadme
+//
 // # Use as a command line tool
 //
-//	$ GO111MODULE=on go get github.com/posener/goreadme/cmd/goreadme
+//	$ go install github.com/posener/goreadme/cmd/goreadme@latest
 //	$ goreadme -h
 //
+// When the given package is a local path (or omitted), it is loaded from the filesystem according
+// to its go.mod file, without accessing the network.
+//
+// A remote package is rendered from its default branch. To render a specific version, for example
+// for a release branch, pass a tag, branch or commit with the `-ref` flag: the package and its sub
+// packages are then loaded from the Go module proxy at this version.
+//
+// To verify that an existing README file is up to date without changing it, for example in a CI
+// job, run `goreadme -check`. It prints a diff and exits with a non-zero status if the README is
+// stale.
+//
 // # Pre-Commit hook
 //
 // goreadme can also be used as a pre-commit hook, acting before each commit is made.
@@ -63,6 +116,108 @@
 // 5. Install with `pre-commit install`
 // 6. Now you're all set! Try a commit, see the README being updated (if relevant), and continue your commit.
 //
+// # Custom Templates
+//
+// The README layout can be changed by providing a directory with template files using the
+// `-template-dir` flag. A `main.md.gotmpl` file replaces the whole layout, and other
+// `*.md.gotmpl` files can redefine any of the named templates: "consts", "vars", "functions",
+// "types", "typesConsts", "typesVars", "deprecated", "subpackages", "examples" and
+// "examplesNoHeading". See ./internal/template for the built-in templates and the available
+// template functions.
+//
+// # Partial Updates
+//
+// With the `-markers` flag, goreadme updates only the content between goreadme markers in an
+// existing README file, and keeps everything else untouched. The whole generated content is
+// written between `<!-- goreadme:start -->` and `<!-- goreadme:end -->`, and single sections
+// between their named markers, such as `<!-- goreadme:types:start -->` and
+// `<!-- goreadme:types:end -->`. If the README file does not exist, it is created with markers
+// around each section.
+//
+// # Identifier Links
+//
+// When the functions or types sections are written, the exported identifiers of the package that
+// appear in the doc text link to their sections. Qualified names link to the method section, such
+// as `Type.Method`, or to the type section for fields, such as `Type.Field`. Doc links, such as
+// `[Type]`, are replaced by the link, and the doc of an identifier does not link to its own
+// section.
+//
+// # Deprecated Identifiers
+//
+// Identifiers whose doc has a paragraph that starts with "Deprecated: ", following the Go
+// convention, can be rendered differently with the `-deprecated` flag: `hide` omits them from the
+// README, `section` moves them to a Deprecated section after the types, and `mark` adds a
+// deprecated label to their headings, followed by the replacement advice. Deprecated struct
+// fields and grouped constants and variables are removed from their declarations in the first two
+// modes.
+//
+// # Table of Contents
+//
+// With the `-toc` flag, a linked table of contents is added after the package doc. It lists the
+// headings of the package doc and of the generated sections, with Github anchors. The `-toc-depth`
+// flag limits the listed heading levels.
+//
+// # API Reference File
+//
+// With the `-api-file` flag, for example `-api-file=API.md`, the README contains only an overview
+// of the package: the badges, the package doc, the sub packages and the examples. The constants,
+// variables, functions and types are written to the given API reference file, next to the README,
+// and the two files link to each other.
+//
+// # Command Flags
+//
+// With the `-flags` flag, the README of a command, a main package, has a Flags section with a
+// table of the flags that it defines with the standard library flag package: their names, types,
+// default values and usage.
+//
+// # Config File
+//
+// Instead of passing flags, the configuration can be kept in a `.goreadme.json` or
+// `.goreadme.yaml` file. The file is looked up in the package directory and its parents, up to
+// the module root, and the closest file is used. The file fields are the JSON names of the Config
+// fields, for example:
+//
+//	types: true
+//	functions: true
+//	badges:
+//	  go_doc: true
+//
+// Flags that are given in the command line override the values from the config file. In the
+// Github action, all the inputs are passed as flags, so only inputs that are set to a value other
+// than their default override the config file: an input can't reset a config file value to the
+// default. Relative `template_dir` and `api_file` paths in the config file are relative to the
+// config file directory. With the `-all` flag, the closest config file is looked up for every
+// package, and `api_file` is relative to each README directory.
+//
+// # Badges
+//
+// Built-in badges are enabled with the `-badge-*` flags. Custom badges can be defined in the
+// config file, with templates that can use the package import path:
+//
+//	custom_badges:
+//	  - name: coverage
+//	    alt: Coverage
+//	    image: https://coverage.example.com/{{fullName}}.svg
+//	    link: https://coverage.example.com/{{importPath}}
+//	badge_order: [coverage, go_doc]
+//
+// # Whole Module
+//
+// With the `-all` flag, goreadme writes a README file in the directory of every package in the
+// module, using the same flags for all of them. The module is loaded once, only changed files are
+// written, and the list of changed files is printed.
+//
+// # API Diff
+//
+// `goreadme apidiff <old-ref> <new-ref>` prints the changes in the exported API of the package
+// between two refs as markdown: the added, removed and changed constants, variables, functions,
+// types, methods and fields, with the changes that might break users listed first. A local
+// package is checked out at each ref in a temporary git worktree, and a remote package is loaded
+// from the Go module proxy at each version. In pull requests, the Github action adds these
+// changes, compared to the base branch, to the pull request comment when the `pr-apidiff` input
+// is set. This requires the base branch to be fetched, for example with `fetch-depth: 0` in the
+// checkout step.
+//
 // # Why Should You Use It
 //
 // Both Go doc and readme files are important. Go doc to be used by your user's library, and README
@@ -122,19 +277,22 @@
 package goreadme
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/golang/gddo/doc"
 	"github.com/pkg/errors"
-	"github.com/posener/goreadme/internal/markdown"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/posener/goreadme/internal/template"
 )
 
@@ -152,7 +310,12 @@ func New(c *http.Client) *GoReadme {
 // GoReadme enables getting readme.md text from a go package.
 type GoReadme struct {
 	client *http.Client
+	source Source
 	config Config
+	// configFiles is set if WriteAll loads the config file of each package.
+	configFiles bool
+	// override is applied to the configuration of each package after loading its config file.
+	override func(*Config)
 }
 
 type Config struct {
@@ -164,6 +327,8 @@ type Config struct {
 	// GoDocURL is the Go Doc URL used in the GoDoc Badge. Default: https://pkg.go.dev.
 	GoDocURL string `json:"godoc_url"`
 	// Use the standard library comment parser introduced in Go 1.19 to generate the markdown output.
+	// Doc links, such as `[Name]` or `[pkg.Name]`, are linked to the Go doc of the symbol, and lists
+	// and headings follow the Go doc comment syntax.
 	StdMarkdown bool `json:"std_markdown"`
 	// RenderTypeContent will render fulll type content instead of an ellipsis (`{ ... }`).
 	RenderTypeContent bool `json:"render_type_content"`
@@ -187,21 +352,91 @@ type Config struct {
 	SkipExamples bool `json:"skip_examples"`
 	// SkipSubPackages will omit the sub packages section from the README.
 	SkipSubPackages bool `json:"skip_sub_packages"`
+	// Flags will add the flags section of commands to the README. The flags section is a table of
+	// the flags that a command defines with the standard library flag package.
+	Flags bool `json:"flags"`
 	// NoDiffBlocks disables marking code blocks as diffs if they start with minus or plus signes.
 	NoDiffBlocks bool `json:"no_diff_blocks"`
 	// RecursiveSubPackages will retrieved subpackages information recursively.
 	// If false, only one level of subpackages will be retrieved.
 	RecursiveSubPackages bool `json:"recursive_sub_packages"`
-	Badges               struct {
+	// Branch is the default branch of the repository, which is used in badges and in source links.
+	// If it is set, source links of packages on known hosts link to the files in this branch.
+	// The command line tool detects it from the CI service or from the git remote. Default for
+	// badges: master.
+	Branch string `json:"branch"`
+	// SourceURLPattern is the URL of a file line in the repository, which is used in source links
+	// and in badges. It may use the `{host}`, `{repo}`, `{ref}`, `{refType}`, `{path}` and
+	// `{line}` placeholders, for example:
+	// `https://gitlab.example.com/{repo}/-/blob/{ref}/{path}#L{line}`, where `{line}` must be in
+	// the URL fragment. `{refType}` is branch, tag or commit. `{repo}` is the module path without
+	// the host, or the first two path elements for the github, bitbucket, gitea and sourcehut
+	// presets. It can also be one of the presets: github, gitlab, bitbucket, gitea or sourcehut. If
+	// it is set, links to local paths in the doc, such as `./docs`, also link to the repository.
+	// Default: the preset of the host of the import path, if it is known.
+	SourceURLPattern string `json:"source_url_pattern"`
+	// Ref is a tag, branch or commit of a remote package to render, instead of its default branch.
+	// The package and its sub packages are loaded from the Go module proxy in GOPROXY at this
+	// version, and source links link to the files in this ref. Local packages are not affected.
+	Ref string `json:"ref"`
+	// Deprecated chooses how identifiers that are deprecated with the Go convention, a doc
+	// paragraph that starts with "Deprecated: ", are rendered: "hide" omits them, "section" moves
+	// them to a Deprecated section after the types, and "mark" adds a deprecated label to their
+	// headings, followed by the replacement advice. It applies to functions, types, methods, struct
+	// fields, constants and variables. Default: they are rendered like any other identifier.
+	Deprecated string `json:"deprecated"`
+	// Installation adds an installation section after the package doc, with `go get` for a library
+	// or `go install` for a command, followed by `go install` for commands in the sub packages.
+	Installation bool `json:"installation"`
+	// Badges enables the built-in badges.
+	Badges struct {
 		TravisCI     bool `json:"travis_ci"`
 		CodeCov      bool `json:"code_cov"`
 		GolangCI     bool `json:"golang_ci"`
 		GoDoc        bool `json:"go_doc"`
 		GoReportCard bool `json:"go_report_card"`
+		// GithubActions shows the status of the Github Actions workflow in Workflow.
+		GithubActions bool `json:"github_actions"`
+		// GoVersion shows the Go version from the go.mod file.
+		GoVersion bool `json:"go_version"`
+		// License shows the license of the repository.
+		License bool `json:"license"`
+		// Release shows the latest release of the repository.
+		Release bool `json:"release"`
+		// Workflow is the file name of the Github Actions workflow. Default: test.yml.
+		Workflow string `json:"workflow"`
 	} `json:"badges"`
+	// CustomBadges are badges that are shown after the built-in badges.
+	CustomBadges []Badge `json:"custom_badges"`
+	// BadgeOrder is the order of the badges by their names. The names of the built-in badges are
+	// the JSON names of their Badges fields, such as "go_doc". Badges that are not in the order are
+	// shown after the ordered badges.
+	BadgeOrder []string `json:"badge_order"`
 	// GeneratedFileNotice will add a notice (HTML comment) stating that the README is generated and should probably not be edited.
 	GeneratedNotice bool `json:"generated_notice"`
 	Credit          bool `json:"credit"`
+	// TemplateDir is a directory with template files (`*.md.gotmpl`) that override the built-in
+	// templates. A main.md.gotmpl file replaces the whole README layout, and any other file can
+	// redefine a named template, such as "types" or "subpackages", or define new ones. The
+	// templates can use the built-in template functions.
+	TemplateDir string `json:"template_dir"`
+	// Markers wraps each generated section with named goreadme markers, such as
+	// `<!-- goreadme:types:start -->` and `<!-- goreadme:types:end -->`. When updating an existing
+	// README with Update, only the content between markers is replaced.
+	Markers bool `json:"markers"`
+	// TOC inserts a linked table of contents after the package doc. It lists the headings of the
+	// package doc and of the generated sections.
+	TOC bool `json:"toc"`
+	// TOCDepth is the deepest heading level that is listed in the table of contents. For example,
+	// 2 lists only the `##` headings, and 4 also lists the type methods. Default: 4.
+	TOCDepth int `json:"toc_depth"`
+	// APIFile is the path of an API reference file, relative to the README directory. When set,
+	// the constants, variables, functions and types sections are not written to the README, which
+	// links to the API reference file instead. The API reference file is written by CreateAPI.
+	APIFile string `json:"api_file"`
+	// APIReadmeLink is the link from the API reference file to the README file, relative to the
+	// API reference file. Default: the README.md file in the README directory.
+	APIReadmeLink string `json:"api_readme_link"`
 }
 
 // Create writes the content of readme.md to w, with the default client.
@@ -217,48 +452,223 @@ func (r GoReadme) WithConfig(cfg Config) *GoReadme {
 	return &r
 }
 
+// WithConfigFiles returns a copy of the converter that, in WriteAll, loads the config file closest
+// to each package directory over its configuration. The override function, if not nil, is applied
+// to the configuration of each package after its config file, for example to apply command line
+// flags.
+func (r GoReadme) WithConfigFiles(override func(*Config)) *GoReadme {
+	r.configFiles = true
+	r.override = override
+	return &r
+}
+
+// WithSource returns a copy of the converter that loads packages from the given source.
+func (r GoReadme) WithSource(s Source) *GoReadme {
+	r.source = s
+	return &r
+}
+
 // Create writes the content of readme.md to w, with r's HTTP client.
 // name should be a Go repository name, such as "github.com/posener/goreadme".
 func (r *GoReadme) Create(ctx context.Context, name string, w io.Writer) error {
+	return r.execute(ctx, name, template.Main, w)
+}
+
+// CreateAPI writes to w the API reference file of the package, which is linked from the README
+// when APIFile is configured. It contains the constants, variables, functions and types sections.
+func (r *GoReadme) CreateAPI(ctx context.Context, name string, w io.Writer) error {
+	return r.WithConfig(apiConfig(r.config)).execute(ctx, name, template.API, w)
+}
+
+// apiConfig returns the config of the API reference file, which contains all the API sections.
+func apiConfig(cfg Config) Config {
+	cfg.Consts, cfg.Vars, cfg.Functions, cfg.Types = true, true, true, true
+	cfg.Markers = false
+	return cfg
+}
+
+func (r *GoReadme) execute(ctx context.Context, name, tmpl string, w io.Writer) error {
 	p, err := r.get(ctx, name)
 	if err != nil {
 		return err
 	}
-	return template.Execute(w, p, r.config, markdown.OptNoDiff(r.config.NoDiffBlocks))
+	var overrides fs.FS
+	if dir := r.config.TemplateDir; dir != "" {
+		overrides = os.DirFS(dir)
+	}
+	buf := bytes.NewBuffer(nil)
+	err = template.Execute(buf, tmpl, p, r.config, overrides, r.markdownOptions(p, tmpl)...)
+	if err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	if r.config.TOC {
+		out = insertTOC(out, r.config.TOCDepth)
+	}
+	if r.config.Markers {
+		// Format the marked sections the same way as Update does.
+		out, err = replaceMarked(out, out)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// Update writes to w the existing README, in which the content between goreadme markers is
+// replaced with freshly generated content. Everything outside the markers is kept as is.
+// The whole generated content replaces `<!-- goreadme:start -->` and `<!-- goreadme:end -->`, and
+// a single section replaces its named markers, for example: `<!-- goreadme:badges:start -->` and
+// `<!-- goreadme:badges:end -->`. Available sections are: title, badges, doc, toc, flags,
+// installation, api, consts, vars, functions, types, deprecated, subpackages, examples and credit.
+func (r *GoReadme) Update(ctx context.Context, name string, existing io.Reader, w io.Writer) error {
+	old, err := io.ReadAll(existing)
+	if err != nil {
+		return errors.Wrap(err, "failed reading existing README")
+	}
+	generated := bytes.NewBuffer(nil)
+	err = r.WithConfig(withMarkers(r.config)).Create(ctx, name, generated)
+	if err != nil {
+		return err
+	}
+	updated, err := replaceMarked(old, generated.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(updated)
+	return err
+}
+
+// Check compares the existing README with the README that is generated for the package, and
+// returns a unified diff between them. The diff is empty if the existing README is up to date.
+// If Markers is set and the existing README is not empty, only the marked content is compared.
+func (r *GoReadme) Check(ctx context.Context, name string, existing io.Reader) (diff string, err error) {
+	old, err := io.ReadAll(existing)
+	if err != nil {
+		return "", errors.Wrap(err, "failed reading existing README")
+	}
+	want, err := r.render(ctx, name, old)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(old, want) {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(old)),
+		B:        difflib.SplitLines(string(want)),
+		FromFile: "existing",
+		ToFile:   "generated",
+		Context:  3,
+	})
+}
+
+// render returns the README content that should replace the existing one: with Markers set and
+// an existing README, only the marked content is replaced, otherwise a new README is created.
+func (r *GoReadme) render(ctx context.Context, name string, existing []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	var err error
+	if r.config.Markers && len(existing) > 0 {
+		err = r.Update(ctx, name, bytes.NewReader(existing), buf)
+	} else {
+		err = r.Create(ctx, name, buf)
+	}
+	return buf.Bytes(), err
+}
+
+func withMarkers(cfg Config) Config {
+	cfg.Markers = true
+	return cfg
 }
 
 // pkg contains information about a go package, to be used in the template.
 type pkg struct {
-	Package     *doc.Package
-	SubPackages []subPkg
+	Package      *doc.Package
+	SubPackages  []subPkg
+	Badges       []Badge
+	Installation []string
+	Flags        []flagInfo
+	// Deprecated are the identifiers of the Deprecated section.
+	Deprecated *deprecatedDecls
+
+	// all is the package before the deprecated identifiers were removed.
+	all *doc.Package
+	// imports are the import paths of the package imports by their names, which resolve the
+	// package names in doc links.
+	imports map[string]string
+}
+
+// Commands returns the sub packages that are commands.
+func (p *pkg) Commands() []subPkg {
+	var cmds []subPkg
+	for _, sub := range p.SubPackages {
+		if sub.Package.IsCmd {
+			cmds = append(cmds, sub)
+		}
+	}
+	return cmds
+}
+
+// Libraries returns the sub packages that are not commands.
+func (p *pkg) Libraries() []subPkg {
+	var libs []subPkg
+	for _, sub := range p.SubPackages {
+		if !sub.Package.IsCmd {
+			libs = append(libs, sub)
+		}
+	}
+	return libs
 }
 
 // subPkg is information about sub package, to be used in the template.
 type subPkg struct {
 	Path    string
 	Package *doc.Package
+	// Install is the command that installs the sub package, if it is a command.
+	Install string
+}
+
+// Name returns the name of the sub package directory, which is the binary name of a command.
+func (s subPkg) Name() string {
+	return path.Base(s.Path)
 }
 
 func (r *GoReadme) get(ctx context.Context, name string) (*pkg, error) {
 	log.Printf("Getting %s", name)
-	p, err := docGet(ctx, r.client, name, "")
+	src := r.source
+	if src == nil {
+		ds := &defaultSource{client: r.client, ref: r.config.Ref}
+		defer ds.Close()
+		src = ds
+	}
+	p, err := src.Package(ctx, name)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed getting %s", name)
 	}
+	// Sources may share packages between calls, copy the fields that are modified below.
+	cp := *p
+	p = &cp
+	p.Examples = append([]*doc.Example(nil), p.Examples...)
+	p.Subdirectories = append([]string(nil), p.Subdirectories...)
 	sort.Strings(p.Subdirectories)
 
+	all := *p
+	deprecated := removeDeprecated(p, r.config)
+
 	// If functions were not requested to be added to the readme, add their
 	// examples to the main readme.
 	if !r.config.Functions {
 		for _, f := range p.Funcs {
 			for _, e := range f.Examples {
+				e := *e
 				if e.Name == "" {
 					e.Name = f.Name
 				}
 				if e.Doc == "" {
 					e.Doc = f.Doc
 				}
-				p.Examples = append(p.Examples, e)
+				p.Examples = append(p.Examples, &e)
 			}
 		}
 	}
@@ -268,13 +678,14 @@ func (r *GoReadme) get(ctx context.Context, name string) (*pkg, error) {
 	if !r.config.Types {
 		for _, f := range p.Types {
 			for _, e := range f.Examples {
+				e := *e
 				if e.Name == "" {
 					e.Name = f.Name
 				}
 				if e.Doc == "" {
 					e.Doc = f.Doc
 				}
-				p.Examples = append(p.Examples, e)
+				p.Examples = append(p.Examples, &e)
 			}
 		}
 	}
@@ -293,24 +704,58 @@ func (r *GoReadme) get(ctx context.Context, name string) (*pkg, error) {
 		p.ImportPath = override
 	}
 
-	if override := r.config.GoDocURL; override == "" {
-		r.config.GoDocURL = "https://pkg.go.dev"
-	}
+	setSourceURLs(p, r.config)
 
 	pkg := &pkg{
-		Package: p,
+		Package:    p,
+		Deprecated: deprecated,
+		all:        &all,
+	}
+
+	pkg.Badges, err = badges(p, r.config)
+	if err != nil {
+		return nil, err
 	}
 
 	if !r.config.SkipSubPackages {
 		f := subpackagesFetcher{
 			importPath: name,
-			client:     r.client,
+			source:     src,
 			recursive:  r.config.RecursiveSubPackages,
 		}
-		pkg.SubPackages, err = f.Fetch(ctx, p)
+		pkg.SubPackages, err = f.Fetch(ctx)
 		if err != nil {
 			return nil, err
 		}
+		setInstallCommands(p, pkg.SubPackages)
+	}
+	if r.config.Installation {
+		pkg.Installation = installCommands(p, pkg.SubPackages)
+	}
+
+	// dir contains the source files of the package, if they are available.
+	var dir string
+	if ds, ok := src.(dirSource); ok {
+		dir, err = ds.Dir(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.IsCmd && r.config.Flags && dir != "" {
+		pkg.Flags, err = commandFlags(p, dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.config.StdMarkdown {
+		if dir != "" {
+			pkg.imports, err = localImportNames(ctx, dir, p.Files)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			pkg.imports = guessImportNames(p.Imports)
+		}
 	}
 	debug(pkg)
 	return pkg, nil
@@ -6,10 +6,8 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"testing"
 
-	"github.com/golang/gddo/gosrc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
@@ -22,33 +20,41 @@ var gr = New(oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
 	&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
 )))
 
-func init() {
-	path, err := filepath.Abs("./testdata")
-	if err != nil {
-		panic(err)
-	}
-	gosrc.SetLocalDevMode(path)
-}
-
+// TestCreate runs every testdata package in both markdown modes. The README.md file is the
+// expected output with the package config, and the expected output with the other markdown mode
+// is in README.std.md or README.legacy.md.
 func TestCreate(t *testing.T) {
 	t.Parallel()
 
 	for _, dir := range testDirs(t) {
-		t.Run(dir, func(t *testing.T) {
-			dir := "./" + dir
-			t.Parallel()
-
-			buf := bytes.NewBuffer(nil)
-			cfg := loadConfig(t, dir)
-			t.Logf("Running with config: %+v", cfg)
-			err := gr.WithConfig(cfg).Create(context.Background(), dir, buf)
-			require.NoError(t, err)
-			if writeReadmes {
-				// Helper with writing the README files.
-				require.NoError(t, ioutil.WriteFile(readmeFileName(dir), buf.Bytes(), 0664))
+		for _, std := range []bool{false, true} {
+			dir, std := "./"+dir, std
+			name := "legacy"
+			if std {
+				name = "std"
 			}
-			assertReadme(t, dir, buf.String())
-		})
+			t.Run(dir+"/"+name, func(t *testing.T) {
+				t.Parallel()
+
+				buf := bytes.NewBuffer(nil)
+				cfg := loadConfig(t, dir)
+				fileName := readmeFileName(dir)
+				if cfg.StdMarkdown != std {
+					fileName = dir + "/README." + name + ".md"
+				}
+				cfg.StdMarkdown = std
+				t.Logf("Running with config: %+v", cfg)
+				err := gr.WithConfig(cfg).Create(context.Background(), dir, buf)
+				require.NoError(t, err)
+				if writeReadmes {
+					// Helper with writing the README files.
+					require.NoError(t, ioutil.WriteFile(fileName, buf.Bytes(), 0664))
+				}
+				want, err := ioutil.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.Equal(t, string(want), buf.String())
+			})
+		}
 	}
 }
 
@@ -90,3 +96,41 @@ func loadConfig(t *testing.T, dir string) Config {
 func readmeFileName(dir string) string {
 	return dir + "/README.md"
 }
+
+func TestCreateAPI(t *testing.T) {
+	t.Parallel()
+
+	const dir = "./testdata/pkg21_api_file"
+	cfg := loadConfig(t, dir)
+	buf := bytes.NewBuffer(nil)
+	err := gr.WithConfig(cfg).CreateAPI(context.Background(), dir, buf)
+	require.NoError(t, err)
+
+	path := dir + "/" + cfg.APIFile
+	if writeReadmes {
+		require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0664))
+	}
+	want, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), buf.String())
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	const dir = "./testdata/pkg8_types"
+	g := gr.WithConfig(loadConfig(t, dir))
+
+	want, err := ioutil.ReadFile(readmeFileName(dir))
+	require.NoError(t, err)
+
+	diff, err := g.Check(context.Background(), dir, bytes.NewReader(want))
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	stale := bytes.Replace(want, []byte("ExampleType is a type"), []byte("ExampleType is stale"), 1)
+	diff, err = g.Check(context.Background(), dir, bytes.NewReader(stale))
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- existing\n+++ generated\n")
+	assert.Contains(t, diff, "\n-ExampleType is stale\n+ExampleType is a type\n")
+}
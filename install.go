@@ -0,0 +1,65 @@
+package goreadme
+
+import (
+	"strings"
+
+	"github.com/golang/gddo/doc"
+	"golang.org/x/mod/module"
+)
+
+// installCommands returns the commands that install the package: `go get` for a library and
+// `go install` for a command, followed by `go install` for the commands in the sub packages.
+// Packages without an import path, such as local packages that are not in a module, have no
+// install commands.
+func installCommands(p *doc.Package, subPkgs []subPkg) []string {
+	importPath := withMajorVersion(p.ImportPath, p.ProjectRoot)
+	if importPath == "" || isLocal(importPath) {
+		return nil
+	}
+	var cmds []string
+	if p.IsCmd {
+		cmds = append(cmds, goInstall(importPath))
+	} else {
+		cmds = append(cmds, "go get "+importPath)
+	}
+	for _, sub := range subPkgs {
+		if sub.Install != "" {
+			cmds = append(cmds, sub.Install)
+		}
+	}
+	return cmds
+}
+
+// setInstallCommands sets the install command of the sub packages that are commands.
+func setInstallCommands(p *doc.Package, subPkgs []subPkg) {
+	importPath := withMajorVersion(p.ImportPath, p.ProjectRoot)
+	if importPath == "" || isLocal(importPath) {
+		return
+	}
+	for i, sub := range subPkgs {
+		if sub.Package != nil && sub.Package.IsCmd {
+			subPkgs[i].Install = goInstall(importPath + "/" + sub.Path)
+		}
+	}
+}
+
+func goInstall(importPath string) string {
+	return "go install " + importPath + "@latest"
+}
+
+// withMajorVersion adds the major version suffix of the module path, such as `/v2`, to the import
+// path, if it is missing. This happens when the import path is overridden with the repository
+// path.
+func withMajorVersion(importPath, modulePath string) string {
+	prefix, major, ok := module.SplitPathVersion(modulePath)
+	if !ok || major == "" || !strings.HasPrefix(major, "/") {
+		return importPath
+	}
+	if importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/") {
+		return importPath
+	}
+	if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+		return prefix + major + strings.TrimPrefix(importPath, prefix)
+	}
+	return importPath
+}
@@ -0,0 +1,48 @@
+package goreadme
+
+import (
+	"testing"
+
+	"github.com/golang/gddo/doc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		p    doc.Package
+		want []string
+	}{
+		{
+			name: "library",
+			p:    doc.Package{ImportPath: "example.com/lib", ProjectRoot: "example.com/lib"},
+			want: []string{"go get example.com/lib"},
+		},
+		{
+			name: "command",
+			p:    doc.Package{ImportPath: "example.com/lib/cmd/tool", ProjectRoot: "example.com/lib", IsCmd: true},
+			want: []string{"go install example.com/lib/cmd/tool@latest"},
+		},
+		{
+			name: "major version",
+			p:    doc.Package{ImportPath: "github.com/user/repo", ProjectRoot: "github.com/user/repo/v2"},
+			want: []string{"go get github.com/user/repo/v2"},
+		},
+		{
+			name: "outside a module",
+			p:    doc.Package{ImportPath: "./tool", IsCmd: true},
+		},
+		{
+			name: "current directory outside a module",
+			p:    doc.Package{ImportPath: "."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.p
+			assert.Equal(t, tt.want, installCommands(&p, nil))
+		})
+	}
+}
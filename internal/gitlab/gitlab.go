@@ -0,0 +1,149 @@
+// Package gitlab is a minimal client of the GitLab REST API, which is used in GitLab CI mode.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultBaseURL is the base URL of the gitlab.com REST API.
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// Client is a GitLab REST API client.
+type Client struct {
+	// BaseURL is the base URL of the REST API. Default: DefaultBaseURL.
+	BaseURL string
+	// Token is a personal, project or group access token.
+	Token string
+	// HTTPClient is used for the requests. Default: http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Note is a merge request note.
+type Note struct {
+	ID     int64  `json:"id"`
+	Body   string `json:"body"`
+	Author struct {
+		ID int64 `json:"id"`
+	} `json:"author"`
+}
+
+// CreateMergeRequestNote posts a note with the given body on a merge request. The project is the
+// project ID or its path with namespace, such as `group/project`, and mergeRequest is the merge
+// request IID.
+func (c *Client) CreateMergeRequestNote(ctx context.Context, project, mergeRequest, body string) error {
+	_, err := c.do(ctx, http.MethodPost, notesPath(project, mergeRequest), map[string]string{"body": body}, nil)
+	return err
+}
+
+// UpdateMergeRequestNote edits the body of a note of a merge request.
+func (c *Client) UpdateMergeRequestNote(ctx context.Context, project, mergeRequest string, note int64, body string) error {
+	path := notesPath(project, mergeRequest) + "/" + strconv.FormatInt(note, 10)
+	_, err := c.do(ctx, http.MethodPut, path, map[string]string{"body": body}, nil)
+	return err
+}
+
+// MergeRequestNotes returns all the notes of a merge request.
+func (c *Client) MergeRequestNotes(ctx context.Context, project, mergeRequest string) ([]Note, error) {
+	var notes []Note
+	page := "1"
+	for page != "" {
+		var pageNotes []Note
+		header, err := c.do(ctx, http.MethodGet, notesPath(project, mergeRequest)+"?per_page=100&page="+page, nil, &pageNotes)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, pageNotes...)
+		page = header.Get("X-Next-Page")
+	}
+	return notes, nil
+}
+
+// CurrentUserID returns the ID of the user of the token. The user of a project or group access
+// token is a bot user.
+func (c *Client) CurrentUserID(ctx context.Context) (int64, error) {
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	_, err := c.do(ctx, http.MethodGet, "/user", nil, &user)
+	return user.ID, err
+}
+
+// PostStickyNote posts a sticky note on a merge request: a single note that is found by the marker
+// that it starts with, and is edited in place in following calls, instead of posting a new note.
+// Only notes of the user of the token are edited.
+func (c *Client) PostStickyNote(ctx context.Context, project, mergeRequest, marker, body string) error {
+	body = marker + "\n\n" + body
+	user, err := c.CurrentUserID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed getting current user")
+	}
+	notes, err := c.MergeRequestNotes(ctx, project, mergeRequest)
+	if err != nil {
+		return errors.Wrap(err, "failed listing notes")
+	}
+	for _, n := range notes {
+		if n.Author.ID == user && strings.HasPrefix(n.Body, marker) {
+			return errors.Wrap(c.UpdateMergeRequestNote(ctx, project, mergeRequest, n.ID, body), "failed editing note")
+		}
+	}
+	return errors.Wrap(c.CreateMergeRequestNote(ctx, project, mergeRequest, body), "failed creating note")
+}
+
+func notesPath(project, mergeRequest string) string {
+	return "/projects/" + url.PathEscape(project) + "/merge_requests/" + url.PathEscape(mergeRequest) + "/notes"
+}
+
+// do sends a request with the JSON encoding of in as its body, if it is not nil, and decodes the
+// JSON response to out, if it is not nil. It returns the response headers.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(baseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, errors.Wrapf(err, "%s %s: invalid response", method, path)
+		}
+	}
+	return resp.Header, nil
+}
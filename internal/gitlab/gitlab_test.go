@@ -0,0 +1,114 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateMergeRequestNote(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v4/projects/group%2Fproject/merge_requests/7/notes", r.URL.RawPath)
+		assert.Equal(t, "token", r.Header.Get("PRIVATE-TOKEN"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer s.Close()
+
+	c := &Client{BaseURL: s.URL + "/api/v4", Token: "token"}
+	err := c.CreateMergeRequestNote(context.Background(), "group/project", "7", "diff")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"body": "diff"}, got)
+}
+
+func TestCreateMergeRequestNoteError(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer s.Close()
+
+	c := &Client{BaseURL: s.URL}
+	err := c.CreateMergeRequestNote(context.Background(), "group/project", "7", "diff")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+}
+
+func TestPostStickyNote(t *testing.T) {
+	t.Parallel()
+
+	const (
+		marker = "<!-- marker -->"
+		notes  = "/projects/group%2Fproject/merge_requests/7/notes"
+		self   = 1
+	)
+	var (
+		mu     sync.Mutex
+		stored []Note
+	)
+	add := func(author int64, body string) {
+		n := Note{ID: int64(len(stored) + 1), Body: body}
+		n.Author.ID = author
+		stored = append(stored, n)
+	}
+	// A note of another user that starts with the marker, on the first page of notes.
+	add(2, marker+"\n\nquoted")
+	for i := 0; i < 100; i++ {
+		add(2, "LGTM")
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var in struct {
+			Body string `json:"body"`
+		}
+		if r.Method != http.MethodGet {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/user":
+			w.Write([]byte(`{"id": 1}`))
+		case r.Method == http.MethodGet && r.URL.RawPath == notes:
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			start, end := (page-1)*100, page*100
+			if end < len(stored) {
+				w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+			} else {
+				end = len(stored)
+			}
+			json.NewEncoder(w).Encode(stored[start:end])
+		case r.Method == http.MethodPost && r.URL.RawPath == notes:
+			add(self, in.Body)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.RawPath, notes+"/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.RawPath, notes+"/"), 10, 64)
+			stored[id-1].Body = in.Body
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	c := &Client{BaseURL: s.URL}
+	ctx := context.Background()
+	require.NoError(t, c.PostStickyNote(ctx, "group/project", "7", marker, "diff 1"))
+	require.NoError(t, c.PostStickyNote(ctx, "group/project", "7", marker, "diff 2"))
+
+	require.Len(t, stored, 102)
+	assert.Equal(t, marker+"\n\nquoted", stored[0].Body)
+	assert.Equal(t, marker+"\n\ndiff 2", stored[101].Body)
+}
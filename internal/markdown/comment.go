@@ -41,11 +41,10 @@ func ToMarkdown(w io.Writer, text string, opts ...Option) {
 	for _, f := range opts {
 		f(&o)
 	}
+	o.skipSelfLinks()
 
 	if o.useStdlib {
-		parser := comment.Parser{Words: o.words}
-		printer := comment.Printer{HeadingLevel: 2}
-		w.Write(printer.Markdown(parser.Parse(text)))
+		stdlibMarkdown(w, text, o)
 		return
 	}
 
@@ -54,7 +53,7 @@ func ToMarkdown(w io.Writer, text string, opts ...Option) {
 		case opPara:
 			// New paragraph
 			for _, line := range b.lines {
-				emphasize(w, line, o.words, false)
+				emphasize(w, line, o.words, o.localLinkURL, false)
 			}
 			fmt.Fprint(w, "\n")
 		case opHead:
@@ -68,7 +67,7 @@ func ToMarkdown(w io.Writer, text string, opts ...Option) {
 			// Code block
 			fmt.Fprintf(w, "```%s\n", b.lang)
 			for _, line := range b.lines {
-				emphasize(w, line, nil, false)
+				emphasize(w, line, nil, nil, false)
 			}
 			fmt.Fprint(w, "```\n\n")
 		}
@@ -91,14 +90,74 @@ func OptNoDiff(noDiffs bool) Option {
 	return func(o *options) { o.noDiffs = noDiffs }
 }
 
+// OptUseStdlib uses the standard library comment parser and printer, introduced in Go 1.19.
 func OptUseStdlib(useStdlib bool) Option {
 	return func(o *options) { o.useStdlib = useStdlib }
 }
 
+// OptLookupSym sets the function that reports whether a symbol exists in the current package, to
+// resolve doc links such as `[Name]` or `[Recv.Name]`. Used only with OptUseStdlib.
+func OptLookupSym(lookupSym func(recv, name string) bool) Option {
+	return func(o *options) { o.lookupSym = lookupSym }
+}
+
+// OptLookupPackage sets the function that resolves a package name to an import path, to resolve
+// doc links such as `[pkg.Name]`. Used only with OptUseStdlib.
+func OptLookupPackage(lookupPackage func(name string) (importPath string, ok bool)) Option {
+	return func(o *options) { o.lookupPackage = lookupPackage }
+}
+
+// OptDocLinkURL sets the function that returns the URL of a doc link. Used only with OptUseStdlib.
+func OptDocLinkURL(docLinkURL func(link *comment.DocLink) string) Option {
+	return func(o *options) { o.docLinkURL = docLinkURL }
+}
+
+// OptLocalLinkURL sets the function that returns the URL of a link to a local path, such as
+// `./docs`. By default, the path itself is used as the URL.
+func OptLocalLinkURL(localLinkURL func(path string) string) Option {
+	return func(o *options) { o.localLinkURL = localLinkURL }
+}
+
+// OptIdentifier sets the name of the identifier that the comment documents, such as `Func` or
+// `Type.Method`. Words and doc links that link to its section, which the doc is rendered in, are
+// not linked.
+func OptIdentifier(name string) Option {
+	return func(o *options) { o.identifier = name }
+}
+
 type options struct {
 	words     map[string]string
 	noDiffs   bool
 	useStdlib bool // Use standard library comments parsers introduced in Go 1.19.
+
+	lookupSym     func(recv, name string) bool
+	lookupPackage func(name string) (string, bool)
+	docLinkURL    func(*comment.DocLink) string
+	localLinkURL  func(string) string
+	identifier    string
+}
+
+// skipSelfLinks removes the links to the section of the documented identifier.
+func (o *options) skipSelfLinks() {
+	self := o.words[o.identifier]
+	if o.identifier == "" || self == "" {
+		return
+	}
+	words := make(map[string]string, len(o.words))
+	for word, link := range o.words {
+		if link != self {
+			words[word] = link
+		}
+	}
+	o.words = words
+	if docLinkURL := o.docLinkURL; docLinkURL != nil {
+		o.docLinkURL = func(l *comment.DocLink) string {
+			if url := docLinkURL(l); url != self {
+				return url
+			}
+			return ""
+		}
+	}
 }
 
 const (
@@ -126,7 +185,7 @@ const (
 	localRx = `\.\/[a-zA-Z0-9_@\-\.\/]*`
 )
 
-var matchRx = regexp.MustCompile(`(` + urlTitle + `((` + urlRx + `)|(` + localRx + `)))|(` + identRx + `)`)
+var matchRx = regexp.MustCompile(`(` + urlTitle + `((` + urlRx + `)|(` + localRx + `)))|(` + identRx + `(\.` + identRx + `)?)`)
 
 // pairedParensPrefixLen returns the length of the longest prefix of s containing paired parentheses.
 func pairedParensPrefixLen(s string) int {
@@ -157,10 +216,12 @@ func pairedParensPrefixLen(s string) int {
 // into a link). Go identifiers that appear in the words map are italicized; if
 // the corresponding map value is not the empty string, it is considered a URL
 // and the word is converted into a link.
-func emphasize(w io.Writer, line string, words map[string]string, nice bool) {
+func emphasize(w io.Writer, line string, words map[string]string, localLinkURL func(string) string, nice bool) {
 	if line[len(line)-1] != '\n' {
 		line = line + "\n"
 	}
+	// written is the text of the line that was written so far.
+	written := ""
 	for {
 		m := matchRx.FindStringSubmatchIndex(line)
 		if m == nil {
@@ -168,8 +229,9 @@ func emphasize(w io.Writer, line string, words map[string]string, nice bool) {
 		}
 		// m >= 6 (two parenthesized sub-regexps in matchRx, 1st one is urlRx)
 
-		// write text before match
-		fmt.Fprint(w, line[0:m[0]])
+		// text before match, which is written with the match
+		before := line[0:m[0]]
+		written += before
 		// adjust match if necessary
 		match := line[m[0]:m[1]]
 		if n := pairedParensPrefixLen(match); n < len(match) {
@@ -184,8 +246,27 @@ func emphasize(w io.Writer, line string, words map[string]string, nice bool) {
 		title := ""
 		image := false
 		italics := false
-		if words != nil {
+		if words != nil && m[2] < 0 {
+			if _, ok := words[match]; !ok {
+				// A qualified identifier that is not known, such as `Type.Field`, is matched by
+				// its first part.
+				if i := strings.IndexByte(match, '.'); i > 0 {
+					if _, ok := words[match[:i]]; ok {
+						m[1] = m[0] + i
+						match = match[:i]
+					}
+				}
+			}
 			url, italics = words[match]
+			// Don't link words inside inline code.
+			if strings.Count(written, "`")%2 == 1 {
+				url, italics = "", false
+			}
+			if url != "" {
+				// A link to a known word.
+				title = match
+				italics = false
+			}
 		}
 
 		// If the url ends with a punctuation mark, we will hold it here.
@@ -210,6 +291,7 @@ func emphasize(w io.Writer, line string, words map[string]string, nice bool) {
 
 			// Skip Go path ellipsis.
 			if strings.HasSuffix(url, "/...") {
+				fmt.Fprint(w, before)
 				fmt.Fprint(w, line[1:m[1]])
 				line = line[m[1]:]
 				continue
@@ -225,9 +307,20 @@ func emphasize(w io.Writer, line string, words map[string]string, nice bool) {
 				url = url[:len(url)-1]
 			}
 
+			if localLinkURL != nil && strings.HasPrefix(url, "./") {
+				url = localLinkURL(url)
+			}
+
 			italics = false // don't italicize URLs
 		}
 
+		// A doc link to a known word, such as `[Name]`, is replaced by the link.
+		if m[2] < 0 && url != "" && strings.HasSuffix(before, "[") && strings.HasPrefix(line[m[1]:], "]") {
+			before = before[:len(before)-1]
+			m[1]++
+		}
+		fmt.Fprint(w, before)
+
 		// write match
 		if image {
 			fmt.Fprint(w, "!")
@@ -254,6 +347,7 @@ func emphasize(w io.Writer, line string, words map[string]string, nice bool) {
 		fmt.Fprint(w, after)
 
 		// advance
+		written += line[m[0]:m[1]]
 		line = line[m[1]:]
 	}
 	fmt.Fprint(w, line)
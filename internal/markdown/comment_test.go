@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToMarkdownWords(t *testing.T) {
+	t.Parallel()
+
+	words := map[string]string{
+		"Client":       "#type-client",
+		"Client.Close": "#type-client",
+		"Run":          "#func-run",
+	}
+	tests := []struct {
+		name       string
+		text       string
+		identifier string
+		want       string
+	}{
+		{
+			name: "words",
+			text: "Run runs a Client.",
+			want: "[Run](#func-run) runs a [Client](#type-client).\n\n",
+		},
+		{
+			name: "doc links",
+			text: "Run runs a [Client], not an [Other] or [Client.Close].",
+			want: "[Run](#func-run) runs a [Client](#type-client), not an [Other] or [Client.Close](#type-client).\n\n",
+		},
+		{
+			name:       "own section",
+			text:       "Client is a client. [Client.Close] closes it, and Run runs it.",
+			identifier: "Client",
+			want:       "Client is a client. [Client.Close] closes it, and [Run](#func-run) runs it.\n\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var b strings.Builder
+			ToMarkdown(&b, tt.text, OptWords(words), OptIdentifier(tt.identifier))
+			assert.Equal(t, tt.want, b.String())
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package markdown
+
+import "strings"
+
+// deprecatedPrefix starts the paragraph of a doc comment that marks an identifier as deprecated.
+const deprecatedPrefix = "Deprecated: "
+
+// SplitDeprecated splits the Go deprecation paragraph, which starts with "Deprecated: ", from a
+// doc comment. It returns the doc without this paragraph and the advice that follows the prefix,
+// joined to a single line. ok is false if the doc does not have a deprecation paragraph. Windows
+// line endings are converted in the returned doc.
+func SplitDeprecated(doc string) (text, advice string, ok bool) {
+	doc = strings.ReplaceAll(doc, "\r\n", "\n")
+	paragraphs := strings.Split(doc, "\n\n")
+	for i, p := range paragraphs {
+		if !strings.HasPrefix(p, deprecatedPrefix) {
+			continue
+		}
+		advice = strings.Join(strings.Fields(strings.TrimPrefix(p, deprecatedPrefix)), " ")
+		rest := append(append([]string(nil), paragraphs[:i]...), paragraphs[i+1:]...)
+		return strings.Join(rest, "\n\n"), advice, true
+	}
+	return doc, "", false
+}
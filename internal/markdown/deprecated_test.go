@@ -0,0 +1,72 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitDeprecated(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		doc        string
+		wantText   string
+		wantAdvice string
+		wantOK     bool
+	}{
+		{
+			name:     "not deprecated",
+			doc:      "F does things.\n\nIt is not Deprecated: really.\n",
+			wantText: "F does things.\n\nIt is not Deprecated: really.\n",
+		},
+		{
+			name:       "last paragraph",
+			doc:        "F does things.\n\nDeprecated: Use G\ninstead.\n",
+			wantText:   "F does things.",
+			wantAdvice: "Use G instead.",
+			wantOK:     true,
+		},
+		{
+			name:       "middle paragraph",
+			doc:        "F does things.\n\nDeprecated: Use G.\n\nF returns an error.\n",
+			wantText:   "F does things.\n\nF returns an error.\n",
+			wantAdvice: "Use G.",
+			wantOK:     true,
+		},
+		{
+			name:       "only paragraph",
+			doc:        "Deprecated: Use G.",
+			wantAdvice: "Use G.",
+			wantOK:     true,
+		},
+		{
+			name:       "windows line endings",
+			doc:        "F does things.\r\n\r\nDeprecated: Use G\r\ninstead.\r\n\r\nF returns an error.\r\n",
+			wantText:   "F does things.\n\nF returns an error.\n",
+			wantAdvice: "Use G instead.",
+			wantOK:     true,
+		},
+		{
+			name:     "windows line endings not deprecated",
+			doc:      "F does things.\r\n",
+			wantText: "F does things.\n",
+		},
+		{
+			name:     "prefix without space",
+			doc:      "F does things.\n\nDeprecated:Use G.\n",
+			wantText: "F does things.\n\nDeprecated:Use G.\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			text, advice, ok := SplitDeprecated(tt.doc)
+			assert.Equal(t, tt.wantText, text)
+			assert.Equal(t, tt.wantAdvice, advice)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
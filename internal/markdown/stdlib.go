@@ -0,0 +1,222 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// localLinkRx matches links to paths in the repository, such as `./docs`.
+	localLinkRx = regexp.MustCompile(localRx)
+	// linkTitleRx matches a link title that is written before a URL, such as `(title) `.
+	linkTitleRx = regexp.MustCompile(`\(([^)]+)\)\W$`)
+)
+
+// stdlibMarkdown converts comment text to Markdown with the go/doc/comment parser and printer.
+// The goreadme extensions are applied on the parsed comment: code blocks are fenced and marked as
+// diffs, inline code in backticks is kept, qualified known words are linked, paths in the
+// repository are linked, and a `(title)` before a URL or a path is used as the link text, or as an
+// image alt text if it starts with `image/`.
+func stdlibMarkdown(w io.Writer, text string, o options) {
+	parser := comment.Parser{
+		Words:         o.words,
+		LookupPackage: o.lookupPackage,
+		LookupSym:     o.lookupSym,
+	}
+	printer := comment.Printer{
+		HeadingLevel: 2,
+		HeadingID:    func(*comment.Heading) string { return "" },
+		DocLinkURL:   o.docLinkURL,
+	}
+	e := extender{words: o.words, localLinkURL: o.localLinkURL}
+	d := parser.Parse(e.hideInlineCode(text))
+	var out bytes.Buffer
+	for i, b := range d.Content {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		switch b := b.(type) {
+		case *comment.Code:
+			fmt.Fprintf(&out, "```%s\n%s```\n", codeLang(b.Text, o.noDiffs), b.Text)
+			continue
+		case *comment.Paragraph:
+			b.Text = e.extend(b.Text)
+		case *comment.Heading:
+			b.Text = e.extend(b.Text)
+		case *comment.List:
+			for _, item := range b.Items {
+				for _, c := range item.Content {
+					if p, ok := c.(*comment.Paragraph); ok {
+						p.Text = e.extend(p.Text)
+					}
+				}
+			}
+		}
+		out.Write(printer.Markdown(&comment.Doc{Content: []comment.Block{b}, Links: d.Links}))
+	}
+	w.Write(e.restoreInlineCode(out.Bytes()))
+}
+
+// codeLang returns the language of a code block: "diff" if it looks like a diff, and otherwise
+// "go".
+func codeLang(code string, noDiffs bool) string {
+	if noDiffs {
+		return "go"
+	}
+	// The code text is unindented. Indent it, as the diff checks expect indented code lines.
+	lines := strings.SplitAfter(strings.TrimSuffix(code, "\n"), "\n")
+	for i, line := range lines {
+		if !isBlank(line) {
+			lines[i] = "\t" + line
+		}
+	}
+	diffChIdx := diffCharIdx(lines[0])
+	anyDiff := false
+	for _, line := range lines[1:] {
+		if isValidDiffLine(line, diffChIdx) {
+			return "go"
+		}
+		if isDiffLine(line, diffChIdx) {
+			anyDiff = true
+		}
+	}
+	if anyDiff {
+		return "diff"
+	}
+	return "go"
+}
+
+var (
+	inlineCodeRx  = regexp.MustCompile("`[^`\n]+`")
+	placeholderRx = regexp.MustCompile("\x00([0-9]+)\x00")
+	qualifiedRx   = regexp.MustCompile(`^\.` + identRx)
+)
+
+// extender applies the goreadme extensions on a parsed comment.
+type extender struct {
+	words        map[string]string
+	localLinkURL func(string) string
+	// codes are the inline code spans, which are replaced with placeholders in the comment text,
+	// so that they are not parsed and not escaped by the printer.
+	codes []string
+}
+
+func (e *extender) hideInlineCode(text string) string {
+	return inlineCodeRx.ReplaceAllStringFunc(text, func(code string) string {
+		e.codes = append(e.codes, code)
+		return fmt.Sprintf("\x00%d\x00", len(e.codes)-1)
+	})
+}
+
+func (e *extender) restoreInlineCode(md []byte) []byte {
+	return placeholderRx.ReplaceAllFunc(md, func(m []byte) []byte {
+		var i int
+		fmt.Sscanf(string(m[1:len(m)-1]), "%d", &i)
+		return []byte(e.codes[i])
+	})
+}
+
+// extend applies the goreadme link extensions on a parsed comment text.
+func (e *extender) extend(text []comment.Text) []comment.Text {
+	var out []comment.Text
+	for i := 0; i < len(text); i++ {
+		t := text[i]
+		if l, ok := t.(*comment.Link); ok && len(l.Text) == 1 {
+			if it, ok := l.Text[0].(comment.Italic); ok {
+				// A link to a known word, which is not italicized. A qualified word, such as
+				// `Type.Name`, is linked as a whole if it is known.
+				word := string(it)
+				if i+1 < len(text) {
+					if next, ok := text[i+1].(comment.Plain); ok {
+						if q := qualifiedRx.FindString(string(next)); q != "" {
+							if url, ok := e.words[word+q]; ok && url != "" {
+								word += q
+								l.URL = url
+								text[i+1] = next[len(q):]
+							}
+						}
+					}
+				}
+				l.Text = []comment.Text{comment.Plain(word)}
+			}
+		}
+		plain, ok := t.(comment.Plain)
+		if !ok {
+			out = append(out, t)
+			continue
+		}
+		s := string(plain)
+		// A title before an automatic link: `(title) https://...`.
+		if l, ok := nextAutoLink(text, i); ok {
+			if title, rest, ok := linkTitle(s); ok {
+				s = rest
+				if strings.HasPrefix(title, "image/") {
+					title = strings.TrimPrefix(title, "image/")
+					s += "!"
+				}
+				l.Auto = false
+				l.Text = []comment.Text{comment.Plain(title)}
+			}
+		}
+		out = append(out, e.localLinks(s)...)
+	}
+	return out
+}
+
+func nextAutoLink(text []comment.Text, i int) (*comment.Link, bool) {
+	if i+1 >= len(text) {
+		return nil, false
+	}
+	l, ok := text[i+1].(*comment.Link)
+	return l, ok && l.Auto
+}
+
+// linkTitle returns the link title at the end of s, and s without it.
+func linkTitle(s string) (title, rest string, ok bool) {
+	m := linkTitleRx.FindStringSubmatchIndex(s)
+	if m == nil {
+		return "", s, false
+	}
+	return s[m[2]:m[3]], s[:m[0]], true
+}
+
+// localLinks converts paths in the repository in s, such as `./docs`, to links.
+func (e *extender) localLinks(s string) []comment.Text {
+	var out []comment.Text
+	for {
+		m := localLinkRx.FindStringIndex(s)
+		if m == nil {
+			break
+		}
+		path := s[m[0]:m[1]]
+		if strings.HasSuffix(path, "/...") {
+			// Skip Go path ellipsis.
+			out = append(out, comment.Plain(s[:m[1]]))
+			s = s[m[1]:]
+			continue
+		}
+		path = strings.TrimRight(path, ".,:;?!")
+		before := s[:m[0]]
+		title, before, ok := linkTitle(before)
+		if !ok {
+			title = path
+		}
+		if before != "" {
+			out = append(out, comment.Plain(before))
+		}
+		url := path
+		if e.localLinkURL != nil {
+			url = e.localLinkURL(path)
+		}
+		out = append(out, &comment.Link{Text: []comment.Text{comment.Plain(title)}, URL: url})
+		s = s[m[0]+len(path):]
+	}
+	if s != "" {
+		out = append(out, comment.Plain(s))
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+// Package prcomment manages a sticky pull request comment: a single comment that is found by a
+// hidden marker and edited in place in following runs, instead of posting a new comment.
+package prcomment
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/pkg/errors"
+	"github.com/posener/goaction/actionutil"
+)
+
+// Marker is the hidden marker that identifies the comment.
+const Marker = "<!-- goreadme:comment -->"
+
+// Comment is the sticky comment of a pull request.
+type Comment struct {
+	// Client is a Github client of the pull request repository.
+	Client *actionutil.Client
+	// GraphQLURL is the URL of the GraphQL API. Default: the graphql path of the client base URL.
+	GraphQLURL string
+	// Number is the pull request number.
+	Number int
+}
+
+// Post creates the comment with the given body, or edits it if it already exists. A comment that
+// was minimized by Resolve is shown again.
+func (c *Comment) Post(ctx context.Context, body string) error {
+	existing, err := c.find(ctx)
+	if err != nil {
+		return err
+	}
+	comment := &github.IssueComment{Body: github.String(Marker + "\n\n" + body)}
+	if existing == nil {
+		_, _, err = c.Client.IssuesCreateComment(ctx, c.Number, comment)
+		return errors.Wrap(err, "failed creating comment")
+	}
+	_, _, err = c.Client.IssuesEditComment(ctx, existing.GetID(), comment)
+	if err != nil {
+		return errors.Wrap(err, "failed editing comment")
+	}
+	if existing.minimized {
+		err = c.graphql(ctx, unminimizeMutation, existing.GetNodeID(), nil)
+		return errors.Wrap(err, "failed unminimizing comment")
+	}
+	return nil
+}
+
+// Delete deletes the comment, if it exists.
+func (c *Comment) Delete(ctx context.Context) error {
+	existing, err := c.find(ctx)
+	if err != nil || existing == nil {
+		return err
+	}
+	_, err = c.Client.IssuesDeleteComment(ctx, existing.GetID())
+	return errors.Wrap(err, "failed deleting comment")
+}
+
+// Resolve edits the comment, if it exists, to the given body and minimizes it as resolved.
+func (c *Comment) Resolve(ctx context.Context, body string) error {
+	existing, err := c.find(ctx)
+	if err != nil || existing == nil {
+		return err
+	}
+	comment := &github.IssueComment{Body: github.String(Marker + "\n\n" + body)}
+	_, _, err = c.Client.IssuesEditComment(ctx, existing.GetID(), comment)
+	if err != nil {
+		return errors.Wrap(err, "failed editing comment")
+	}
+	if existing.minimized {
+		return nil
+	}
+	err = c.graphql(ctx, minimizeMutation, existing.GetNodeID(), nil)
+	return errors.Wrap(err, "failed minimizing comment")
+}
+
+// existingComment is the comment with the marker.
+type existingComment struct {
+	*github.IssueComment
+	minimized bool
+}
+
+// find returns the comment with the marker that was posted by the authenticated user, or nil if
+// there is no such comment. Comments of other users that contain the marker, for example by
+// quoting the comment, are ignored.
+func (c *Comment) find(ctx context.Context) (*existingComment, error) {
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.Client.IssuesListComments(ctx, c.Number, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed listing comments")
+		}
+		for _, comment := range comments {
+			if !strings.HasPrefix(comment.GetBody(), Marker) {
+				continue
+			}
+			var data struct {
+				Node struct {
+					ViewerDidAuthor bool `json:"viewerDidAuthor"`
+					IsMinimized     bool `json:"isMinimized"`
+				} `json:"node"`
+			}
+			if err := c.graphql(ctx, commentQuery, comment.GetNodeID(), &data); err != nil {
+				return nil, errors.Wrap(err, "failed getting comment")
+			}
+			if data.Node.ViewerDidAuthor {
+				return &existingComment{IssueComment: comment, minimized: data.Node.IsMinimized}, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// The comment author and minimized state, and the minimize operations, are only available in the
+// GraphQL API.
+const (
+	commentQuery = `query($id: ID!) {
+  node(id: $id) { ... on IssueComment { viewerDidAuthor isMinimized } }
+}`
+	minimizeMutation = `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: RESOLVED}) { clientMutationId }
+}`
+	unminimizeMutation = `mutation($id: ID!) {
+  unminimizeComment(input: {subjectId: $id}) { clientMutationId }
+}`
+)
+
+// graphql runs a GraphQL query with the given node ID, and decodes its result data to data, if it
+// is not nil.
+func (c *Comment) graphql(ctx context.Context, query, nodeID string, data interface{}) error {
+	graphQLURL := c.GraphQLURL
+	if graphQLURL == "" {
+		graphQLURL = "graphql"
+	}
+	req, err := c.Client.NewRequest("POST", graphQLURL, map[string]interface{}{
+		"query":     query,
+		"variables": map[string]string{"id": nodeID},
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.Client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return errors.New(resp.Errors[0].Message)
+	}
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, data)
+}
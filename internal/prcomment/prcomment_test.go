@@ -0,0 +1,247 @@
+package prcomment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/posener/goaction/actionutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPost(t *testing.T) {
+	t.Parallel()
+	api, c := newFakeAPI(t)
+	api.add("LGTM")
+
+	require.NoError(t, c.Post(context.Background(), "diff 1"))
+	require.NoError(t, c.Post(context.Background(), "diff 2"))
+
+	assert.Equal(t, []string{"LGTM", Marker + "\n\ndiff 2"}, api.bodies())
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+	api, c := newFakeAPI(t)
+	api.add("LGTM")
+
+	// Deleting a comment that does not exist does nothing.
+	require.NoError(t, c.Delete(context.Background()))
+	assert.Equal(t, []string{"LGTM"}, api.bodies())
+
+	require.NoError(t, c.Post(context.Background(), "diff"))
+	require.NoError(t, c.Delete(context.Background()))
+	assert.Equal(t, []string{"LGTM"}, api.bodies())
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	api, c := newFakeAPI(t)
+
+	// Resolving a comment that does not exist does nothing.
+	require.NoError(t, c.Resolve(context.Background(), "no changes"))
+	assert.Empty(t, api.bodies())
+	assert.Empty(t, api.minimized)
+
+	require.NoError(t, c.Post(context.Background(), "diff"))
+	require.NoError(t, c.Resolve(context.Background(), "no changes"))
+	assert.Equal(t, []string{Marker + "\n\nno changes"}, api.bodies())
+	assert.Equal(t, []string{"node-1"}, api.minimized)
+}
+
+func TestPostAfterResolve(t *testing.T) {
+	t.Parallel()
+	api, c := newFakeAPI(t)
+
+	require.NoError(t, c.Post(context.Background(), "diff 1"))
+	require.NoError(t, c.Resolve(context.Background(), "no changes"))
+	// Resolving again does not minimize the minimized comment again.
+	require.NoError(t, c.Resolve(context.Background(), "no changes"))
+	assert.Equal(t, []string{"node-1"}, api.minimized)
+
+	require.NoError(t, c.Post(context.Background(), "diff 2"))
+	assert.Equal(t, []string{Marker + "\n\ndiff 2"}, api.bodies())
+	assert.Empty(t, api.minimized)
+}
+
+func TestPostIgnoresOtherUsers(t *testing.T) {
+	t.Parallel()
+	api, c := newFakeAPI(t)
+	// A comment of another user that quotes the comment.
+	api.add(Marker + "\n\nquoted diff")
+
+	require.NoError(t, c.Post(context.Background(), "diff 1"))
+	require.NoError(t, c.Post(context.Background(), "diff 2"))
+	assert.Equal(t, []string{Marker + "\n\nquoted diff", Marker + "\n\ndiff 2"}, api.bodies())
+
+	require.NoError(t, c.Delete(context.Background()))
+	assert.Equal(t, []string{Marker + "\n\nquoted diff"}, api.bodies())
+}
+
+func TestPostPagination(t *testing.T) {
+	t.Parallel()
+	api, c := newFakeAPI(t)
+	for i := 0; i < 150; i++ {
+		api.add(fmt.Sprintf("comment %d", i))
+	}
+	require.NoError(t, c.Post(context.Background(), "diff 1"))
+	require.NoError(t, c.Post(context.Background(), "diff 2"))
+
+	bodies := api.bodies()
+	require.Len(t, bodies, 151)
+	assert.Equal(t, Marker+"\n\ndiff 2", bodies[150])
+}
+
+// fakeAPI is an in-memory Github API of the comments of pull request 1 of owner/repo.
+type fakeAPI struct {
+	mu       sync.Mutex
+	comments []*github.IssueComment
+	lastID   int64
+	// byViewer are the node IDs of the comments that were posted by the authenticated user.
+	byViewer map[string]bool
+	// minimized are the node IDs of the minimized comments, in the order of minimizing.
+	minimized []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *Comment) {
+	api := &fakeAPI{byViewer: make(map[string]bool)}
+	s := httptest.NewServer(api)
+	t.Cleanup(s.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(s.URL + "/")
+	return api, &Comment{
+		Client: &actionutil.Client{Client: client, Owner: "owner", Project: "repo"},
+		Number: 1,
+	}
+}
+
+// add adds a comment of another user.
+func (a *fakeAPI) add(body string) *github.IssueComment {
+	a.lastID++
+	c := &github.IssueComment{
+		ID:     github.Int64(a.lastID),
+		NodeID: github.String("node-" + strconv.FormatInt(a.lastID, 10)),
+		Body:   github.String(body),
+	}
+	a.comments = append(a.comments, c)
+	return c
+}
+
+func (a *fakeAPI) bodies() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var bodies []string
+	for _, c := range a.comments {
+		bodies = append(bodies, c.GetBody())
+	}
+	return bodies
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	const (
+		issueComments = "/repos/owner/repo/issues/1/comments"
+		comment       = "/repos/owner/repo/issues/comments/"
+	)
+	var in struct {
+		Body      string `json:"body"`
+		Query     string `json:"query"`
+		Variables struct {
+			ID string `json:"id"`
+		} `json:"variables"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&in)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == issueComments:
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		start, end := (page-1)*perPage, page*perPage
+		if end < len(a.comments) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, issueComments, page+1))
+		} else {
+			end = len(a.comments)
+		}
+		if start > end {
+			start = end
+		}
+		json.NewEncoder(w).Encode(a.comments[start:end])
+	case r.Method == http.MethodPost && r.URL.Path == issueComments:
+		w.WriteHeader(http.StatusCreated)
+		c := a.add(in.Body)
+		a.byViewer[c.GetNodeID()] = true
+		json.NewEncoder(w).Encode(c)
+	case r.Method == http.MethodPost && r.URL.Path == "/graphql":
+		id := in.Variables.ID
+		switch {
+		case strings.Contains(in.Query, "unminimizeComment"):
+			a.minimized = remove(a.minimized, id)
+		case strings.Contains(in.Query, "minimizeComment"):
+			a.minimized = append(a.minimized, id)
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"node": map[string]bool{
+					"viewerDidAuthor": a.byViewer[id],
+					"isMinimized":     contains(a.minimized, id),
+				},
+			}})
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	case strings.HasPrefix(r.URL.Path, comment):
+		id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, comment), 10, 64)
+		for i, c := range a.comments {
+			if c.GetID() != id {
+				continue
+			}
+			switch r.Method {
+			case http.MethodPatch:
+				c.Body = github.String(in.Body)
+				json.NewEncoder(w).Encode(c)
+			case http.MethodDelete:
+				a.comments = append(a.comments[:i], a.comments[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(ids []string, id string) []string {
+	var kept []string
+	for _, i := range ids {
+		if i != id {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
@@ -2,7 +2,11 @@ package template
 
 import (
 	"embed"
+	"fmt"
 	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"text/template"
@@ -14,13 +18,42 @@ import (
 //go:embed *.md.gotmpl
 var files embed.FS
 
-// Execute is used to execute the README.md template.
-func Execute(w io.Writer, data interface{}, cfg interface{}, options ...markdown.Option) error {
-	templates, err := template.New("main.md.gotmpl").Funcs(funcs(cfg, options)).ParseFS(files, "*")
+// Names of the templates that can be executed.
+const (
+	// Main is the README.md template.
+	Main = "main.md.gotmpl"
+	// API is the API reference template.
+	API = "api.md.gotmpl"
+	// APIDiff is the template of the changes in the exported API between two versions.
+	APIDiff = "apidiff.md.gotmpl"
+)
+
+// Execute is used to execute the named template, Main, API or APIDiff. Templates files
+// (`*.md.gotmpl`) in overrides, if given, replace the embedded templates with the same name or
+// define new ones.
+func Execute(w io.Writer, name string, data interface{}, cfg interface{}, overrides fs.FS, options ...markdown.Option) error {
+	templates, err := template.New(Main).Funcs(funcs(cfg, options)).ParseFS(files, "*")
 	if err != nil {
 		return err
 	}
-	return templates.Execute(&multiNewLineEliminator{w: w}, data)
+	if overrides != nil {
+		templates, err = parseOverrides(templates, overrides)
+		if err != nil {
+			return err
+		}
+	}
+	return templates.ExecuteTemplate(&multiNewLineEliminator{w: w}, name, data)
+}
+
+func parseOverrides(templates *template.Template, overrides fs.FS) (*template.Template, error) {
+	matches, err := fs.Glob(overrides, "*.md.gotmpl")
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return templates, nil
+	}
+	return templates.ParseFS(overrides, matches...)
 }
 
 func funcs(cfg interface{}, options []markdown.Option) template.FuncMap {
@@ -33,6 +66,21 @@ func funcs(cfg interface{}, options []markdown.Option) template.FuncMap {
 			markdown.ToMarkdown(b, s, options...)
 			return b.String()
 		},
+		"docOf": func(name, s string) string {
+			b := &strings.Builder{}
+			markdown.ToMarkdown(b, s, append(options[:len(options):len(options)], markdown.OptIdentifier(name))...)
+			return b.String()
+		},
+		"item": func(name, doc string) item {
+			return item{Name: name, Doc: doc}
+		},
+		"method": func(recv, name string) string {
+			recv = strings.TrimPrefix(recv, "*")
+			if i := strings.IndexByte(recv, '['); i >= 0 {
+				recv = recv[:i]
+			}
+			return recv + "." + name
+		},
 		"gocode": func(s string) string {
 			return "```go\n" + s + "\n```\n"
 		},
@@ -42,13 +90,15 @@ func funcs(cfg interface{}, options []markdown.Option) template.FuncMap {
 			}
 			return "```\n" + s + "```\n"
 		},
-		"inlineCode": func(s string) string {
-			return "`" + s + "`"
+		"inlineCode": codeSpan,
+		"tableCell": func(s string) string {
+			s = strings.ReplaceAll(s, "|", "\\|")
+			return strings.ReplaceAll(s, "\n", " ")
 		},
 		"inlineCodeEllipsis": func(s string) string {
 			r := regexp.MustCompile(`{(?s).*}`)
 			s = r.ReplaceAllString(s, "{ ... }")
-			return "`" + s + "`"
+			return codeSpan(s)
 		},
 		"gocodeEllipsis": func(s string) string {
 			r := regexp.MustCompile(`{(?s).*}`)
@@ -61,11 +111,59 @@ func funcs(cfg interface{}, options []markdown.Option) template.FuncMap {
 		"fullName": func(p *doc.Package) string {
 			return strings.TrimPrefix(p.ImportPath, "github.com/")
 		},
-		"urlOrName": func(f *doc.File) string {
-			if f.URL != "" {
-				return f.URL
+		"readmeLink": func(apiFile, link string) string {
+			if link != "" {
+				return link
 			}
-			return "/" + f.Name
+			dir := path.Dir(filepath.ToSlash(apiFile))
+			if dir == "." {
+				return "README.md"
+			}
+			return strings.Repeat("../", strings.Count(dir, "/")+1) + "README.md"
+		},
+		"urlOrName": urlOrName,
+		"deprecation": func(s string) string {
+			_, advice, _ := markdown.SplitDeprecated(s)
+			return advice
 		},
+		"withoutDeprecation": func(s string) string {
+			text, _, _ := markdown.SplitDeprecated(s)
+			return text
+		},
+		"sourceLine": func(p *doc.Package, pos doc.Pos) string {
+			lineFmt := p.LineFmt
+			if lineFmt == "" {
+				lineFmt = "%s#L%d"
+			}
+			return fmt.Sprintf(lineFmt, urlOrName(p.Files[pos.File]), pos.Line)
+		},
+	}
+}
+
+// item is the doc of an identifier, for the itemDoc template. The name of a method is qualified
+// with its receiver type, and the name of constants and variables is empty.
+type item struct {
+	Name string
+	Doc  string
+}
+
+func urlOrName(f *doc.File) string {
+	if f.URL != "" {
+		return f.URL
+	}
+	return "/" + f.Name
+}
+
+// codeSpan returns s as Markdown inline code. The span is delimited by a backtick string that is
+// longer than any backtick string in s, and s is padded with spaces if it starts or ends with a
+// backtick, so backticks in s, such as in struct tags, do not end the span.
+func codeSpan(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
 	}
+	return fence + s + fence
 }
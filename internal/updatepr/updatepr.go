@@ -0,0 +1,86 @@
+// Package updatepr manages the pull request that updates the readme files: a pull request from a
+// deterministic branch, which is opened or updated when the readme files change and closed when
+// they are up to date.
+package updatepr
+
+import (
+	"context"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/pkg/errors"
+	"github.com/posener/goaction/actionutil"
+)
+
+// DefaultBranch is the default head branch of the pull request.
+const DefaultBranch = "goreadme/update-readme"
+
+// PR is the pull request from the Head branch to the Base branch.
+type PR struct {
+	// Client is a Github client of the repository.
+	Client *actionutil.Client
+	// Head is the branch with the readme changes.
+	Head string
+	// Base is the branch that the changes are merged to.
+	Base string
+}
+
+// Open opens the pull request with the given title and body, or edits them if the pull request is
+// already open. It returns the pull request.
+func (p *PR) Open(ctx context.Context, title, body string) (*github.PullRequest, error) {
+	existing, err := p.find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		pr, _, err := p.Client.PullRequestsEdit(ctx, existing.GetNumber(), &github.PullRequest{
+			Title: github.String(title),
+			Body:  github.String(body),
+		})
+		return pr, errors.Wrap(err, "failed editing pull request")
+	}
+	pr, _, err := p.Client.PullRequestsCreate(ctx, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(p.Head),
+		Base:  github.String(p.Base),
+		Body:  github.String(body),
+	})
+	return pr, errors.Wrap(err, "failed creating pull request")
+}
+
+// Close closes the pull request with the given comment and deletes its branch, if it is open. It
+// returns the closed pull request, or nil if there was no open pull request.
+func (p *PR) Close(ctx context.Context, comment string) (*github.PullRequest, error) {
+	existing, err := p.find(ctx)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	_, _, err = p.Client.IssuesCreateComment(ctx, existing.GetNumber(), &github.IssueComment{Body: github.String(comment)})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed commenting on pull request")
+	}
+	pr, _, err := p.Client.PullRequestsEdit(ctx, existing.GetNumber(), &github.PullRequest{State: github.String("closed")})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed closing pull request")
+	}
+	_, err = p.Client.GitDeleteRef(ctx, "heads/"+p.Head)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed deleting branch %s", p.Head)
+	}
+	return pr, nil
+}
+
+// find returns the open pull request, or nil if there is no such pull request.
+func (p *PR) find(ctx context.Context) (*github.PullRequest, error) {
+	prs, _, err := p.Client.PullRequestsList(ctx, &github.PullRequestListOptions{
+		State: "open",
+		Head:  p.Client.Owner + ":" + p.Head,
+		Base:  p.Base,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed listing pull requests")
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return prs[0], nil
+}
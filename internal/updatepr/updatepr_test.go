@@ -0,0 +1,154 @@
+package updatepr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/posener/goaction/actionutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+	api, p := newFakeAPI(t)
+
+	pr, err := p.Open(context.Background(), "Update readme", "diff 1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pr.GetNumber())
+
+	// A second run edits the open pull request.
+	pr, err = p.Open(context.Background(), "Update readme", "diff 2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pr.GetNumber())
+
+	require.Len(t, api.prs, 1)
+	assert.Equal(t, "diff 2", api.prs[0].GetBody())
+	assert.Equal(t, "open", api.prs[0].GetState())
+	assert.Equal(t, DefaultBranch, api.prs[0].GetHead().GetRef())
+	assert.Equal(t, "main", api.prs[0].GetBase().GetRef())
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	api, p := newFakeAPI(t)
+
+	// Closing when there is no open pull request does nothing.
+	pr, err := p.Close(context.Background(), "up to date")
+	require.NoError(t, err)
+	assert.Nil(t, pr)
+
+	_, err = p.Open(context.Background(), "Update readme", "diff")
+	require.NoError(t, err)
+	pr, err = p.Close(context.Background(), "up to date")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pr.GetNumber())
+
+	assert.Equal(t, "closed", api.prs[0].GetState())
+	assert.Equal(t, []string{"up to date"}, api.comments[1])
+	assert.Equal(t, []string{"heads/" + DefaultBranch}, api.deleted)
+
+	// A new pull request is opened after the previous one was closed.
+	pr, err = p.Open(context.Background(), "Update readme", "diff")
+	require.NoError(t, err)
+	assert.Equal(t, 2, pr.GetNumber())
+}
+
+// fakeAPI is an in-memory Github API of the pull requests of owner/repo.
+type fakeAPI struct {
+	mu       sync.Mutex
+	prs      []*github.PullRequest
+	comments map[int][]string
+	deleted  []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *PR) {
+	api := &fakeAPI{comments: make(map[int][]string)}
+	s := httptest.NewServer(api)
+	t.Cleanup(s.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(s.URL + "/")
+	return api, &PR{
+		Client: &actionutil.Client{Client: client, Owner: "owner", Project: "repo"},
+		Head:   DefaultBranch,
+		Base:   "main",
+	}
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	const (
+		pulls  = "/repos/owner/repo/pulls"
+		issues = "/repos/owner/repo/issues/"
+		refs   = "/repos/owner/repo/git/refs/"
+	)
+	var in struct {
+		github.NewPullRequest
+		State *string `json:"state"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&in)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == pulls:
+		q := r.URL.Query()
+		var prs []*github.PullRequest
+		for _, pr := range a.prs {
+			if pr.GetState() == q.Get("state") && "owner:"+pr.GetHead().GetRef() == q.Get("head") && pr.GetBase().GetRef() == q.Get("base") {
+				prs = append(prs, pr)
+			}
+		}
+		json.NewEncoder(w).Encode(prs)
+	case r.Method == http.MethodPost && r.URL.Path == pulls:
+		pr := &github.PullRequest{
+			Number: github.Int(len(a.prs) + 1),
+			State:  github.String("open"),
+			Title:  in.Title,
+			Body:   in.Body,
+			Head:   &github.PullRequestBranch{Ref: in.Head},
+			Base:   &github.PullRequestBranch{Ref: in.Base},
+		}
+		a.prs = append(a.prs, pr)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(pr)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, pulls+"/"):
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, pulls+"/"))
+		if n < 1 || n > len(a.prs) {
+			http.NotFound(w, r)
+			return
+		}
+		pr := a.prs[n-1]
+		if in.Title != nil {
+			pr.Title = in.Title
+		}
+		if in.Body != nil {
+			pr.Body = in.Body
+		}
+		if in.State != nil {
+			pr.State = in.State
+		}
+		json.NewEncoder(w).Encode(pr)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, issues) && strings.HasSuffix(r.URL.Path, "/comments"):
+		n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, issues), "/comments"))
+		a.comments[n] = append(a.comments[n], in.GetBody())
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(&github.IssueComment{ID: github.Int64(1), Body: in.Body})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, refs):
+		a.deleted = append(a.deleted, strings.TrimPrefix(r.URL.Path, refs))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,434 @@
+package goreadme
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	gddo "github.com/golang/gddo/doc"
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// loadMode is the information that is requested from go/packages. Only names and files are
+// needed, the files are parsed by the builder.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedModule
+
+// isLocal returns true if name is a filesystem path rather than an import path.
+func isLocal(name string) bool {
+	return name == "." || name == ".." ||
+		strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../") ||
+		filepath.IsAbs(name)
+}
+
+// loadLocal loads the documentation of the package in the given directory. The package is
+// resolved with go/packages, which respects the go.mod file of the enclosing module and the build
+// tags given in GOFLAGS. The import path of the package is the module relative import path. The
+// network is never accessed.
+func loadLocal(ctx context.Context, dir string) (*gddo.Package, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := loadLocalPackages(ctx, absDir, ".")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed loading %s", dir)
+	}
+	p, ok := pkgs[absDir]
+	if !ok {
+		return nil, errors.Errorf("package not found in %s", dir)
+	}
+	if p.ImportPath == "" {
+		// Not in a module, keep the path as given.
+		p.ImportPath = dir
+	}
+	return p, nil
+}
+
+// loadLocalPackages loads the documentation of all the packages that match the pattern in the
+// given absolute directory, in a single load. The packages are mapped by their directory.
+func loadLocalPackages(ctx context.Context, dir, pattern string) (map[string]*gddo.Package, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    loadMode,
+		Dir:     dir,
+		Tests:   true,
+		Env:     append(os.Environ(), "GOPROXY=off", "GOFLAGS="+readonlyGoFlags()),
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the packages themselves, and the test files from their test variants, which have IDs
+	// such as "pkg [pkg.test]" and "pkg_test [pkg.test]".
+	var (
+		roots     []*packages.Package
+		testFiles = make(map[string][]string)
+	)
+	for _, p := range pkgs {
+		switch {
+		case strings.HasSuffix(p.ID, ".test"):
+			// Generated test main package.
+		case p.ID == p.PkgPath:
+			roots = append(roots, p)
+		case strings.HasSuffix(p.ID, ".test]"):
+			forPkg := p.ID[strings.Index(p.ID, "[")+1 : len(p.ID)-len(".test]")]
+			for _, f := range p.GoFiles {
+				if strings.HasSuffix(f, "_test.go") {
+					testFiles[forPkg] = append(testFiles[forPkg], f)
+				}
+			}
+		}
+	}
+
+	result := make(map[string]*gddo.Package, len(roots))
+	for _, lp := range roots {
+		pkgDir := dir
+		switch {
+		case len(lp.GoFiles) > 0:
+			pkgDir = filepath.Dir(lp.GoFiles[0])
+		case pattern != ".":
+			// A directory with only test files, which would otherwise take the place of the
+			// package in dir.
+			continue
+		}
+		p, err := buildLocal(lp, pkgDir, testFiles[lp.PkgPath])
+		if err != nil {
+			return nil, err
+		}
+		result[pkgDir] = p
+	}
+	return result, nil
+}
+
+// buildLocal builds the documentation of a loaded package.
+func buildLocal(lp *packages.Package, dir string, testFiles []string) (*gddo.Package, error) {
+	subdirs, err := localSubdirs(dir)
+	if err != nil {
+		return nil, err
+	}
+	p := &gddo.Package{Subdirectories: subdirs}
+	if lp.Module != nil {
+		p.ImportPath = lp.PkgPath
+		p.ProjectRoot = lp.Module.Path
+	}
+	for _, e := range lp.Errors {
+		p.Errors = append(p.Errors, e.Error())
+	}
+	if len(lp.GoFiles) == 0 {
+		// Not a Go package, only the sub directories are relevant.
+		return p, nil
+	}
+
+	b := builder{fset: token.NewFileSet(), files: map[string]int{}}
+	err = b.build(p, lp.GoFiles, testFiles)
+	return p, err
+}
+
+// localImportNames returns the import paths of the imports of the package files in dir, by the
+// names that the files use for them: the names of renamed imports, or the names of the imported
+// packages, which are loaded from dir without accessing the network. The names of imports that
+// can't be loaded are guessed from their import paths.
+func localImportNames(ctx context.Context, dir string, files []*gddo.File) (map[string]string, error) {
+	names := make(map[string]string)
+	var unnamed []string
+	fset := token.NewFileSet()
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name)
+		f, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed parsing %s", path)
+		}
+		for _, imp := range f.Imports {
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			switch {
+			case imp.Name == nil:
+				unnamed = append(unnamed, importPath)
+			case imp.Name.Name != "_" && imp.Name.Name != ".":
+				names[imp.Name.Name] = importPath
+			}
+		}
+	}
+	if len(unnamed) == 0 {
+		return names, nil
+	}
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName,
+		Dir:     dir,
+		Env:     append(os.Environ(), "GOPROXY=off", "GOFLAGS="+readonlyGoFlags()),
+	}
+	pkgs, err := packages.Load(cfg, unnamed...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed loading imports of %s", dir)
+	}
+	for _, p := range pkgs {
+		name, importPath := p.Name, p.PkgPath
+		if importPath == "" {
+			importPath = p.ID
+		}
+		if name == "" {
+			name = importName(importPath)
+		}
+		if _, ok := names[name]; !ok {
+			names[name] = importPath
+		}
+	}
+	return names, nil
+}
+
+// readonlyGoFlags returns the GOFLAGS of the environment, with the -mod flag set to readonly, so
+// the go.mod file of the loaded module is never modified.
+func readonlyGoFlags() string {
+	var flags []string
+	for _, f := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if !strings.HasPrefix(f, "-mod=") {
+			flags = append(flags, f)
+		}
+	}
+	return strings.Join(append(flags, "-mod=readonly"), " ")
+}
+
+// localSubdirs returns the directories in dir that may contain packages of the same module.
+func localSubdirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading %s", dir)
+	}
+	var subdirs []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			continue
+		}
+		// A directory with go.mod file is a different module.
+		if _, err := os.Stat(filepath.Join(dir, name, "go.mod")); err == nil {
+			continue
+		}
+		subdirs = append(subdirs, name)
+	}
+	return subdirs, nil
+}
+
+// builder builds a package documentation from source files.
+type builder struct {
+	fset *token.FileSet
+	// files maps file names to their index in the package files list.
+	files    map[string]int
+	examples []*doc.Example
+}
+
+func (b *builder) build(p *gddo.Package, goFiles, testFiles []string) error {
+	sort.Strings(goFiles)
+	var files []*ast.File
+	for i, path := range goFiles {
+		f, err := parser.ParseFile(b.fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return errors.Wrapf(err, "failed parsing %s", path)
+		}
+		name := filepath.Base(path)
+		b.files[path] = i
+		files = append(files, f)
+		p.Files = append(p.Files, &gddo.File{Name: name})
+	}
+
+	sort.Strings(testFiles)
+	for _, path := range testFiles {
+		f, err := parser.ParseFile(b.fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return errors.Wrapf(err, "failed parsing %s", path)
+		}
+		b.examples = append(b.examples, doc.Examples(f)...)
+		p.TestFiles = append(p.TestFiles, &gddo.File{Name: filepath.Base(path)})
+	}
+
+	dp, err := doc.NewFromFiles(b.fset, files, p.ImportPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed reading doc of %s", p.ImportPath)
+	}
+
+	p.Name = dp.Name
+	p.Doc = strings.TrimRight(dp.Doc, " \t\n\r")
+	p.Synopsis = dp.Synopsis(p.Doc)
+	p.IsCmd = dp.Name == "main"
+	p.Examples = b.getExamples("")
+	p.Consts = b.values(dp.Consts)
+	p.Vars = b.values(dp.Vars)
+	p.Funcs = b.funcs(dp.Funcs)
+	p.Types = b.types(dp.Types)
+	p.Imports = dp.Imports
+	return nil
+}
+
+func (b *builder) values(vdocs []*doc.Value) []*gddo.Value {
+	var result []*gddo.Value
+	for _, d := range vdocs {
+		result = append(result, &gddo.Value{
+			Decl: b.printDecl(d.Decl),
+			Pos:  b.position(d.Decl),
+			Doc:  d.Doc,
+		})
+	}
+	return result
+}
+
+func (b *builder) funcs(fdocs []*doc.Func) []*gddo.Func {
+	var result []*gddo.Func
+	for _, d := range fdocs {
+		exampleName := d.Name
+		if d.Recv != "" {
+			exampleName = strings.TrimPrefix(d.Recv, "*") + "_" + d.Name
+		}
+		result = append(result, &gddo.Func{
+			Decl:     b.printDecl(d.Decl),
+			Pos:      b.position(d.Decl),
+			Doc:      d.Doc,
+			Name:     d.Name,
+			Recv:     d.Recv,
+			Orig:     d.Orig,
+			Examples: b.getExamples(exampleName),
+		})
+	}
+	return result
+}
+
+func (b *builder) types(tdocs []*doc.Type) []*gddo.Type {
+	var result []*gddo.Type
+	for _, d := range tdocs {
+		result = append(result, &gddo.Type{
+			Doc:      d.Doc,
+			Name:     d.Name,
+			Decl:     b.printDecl(d.Decl),
+			Pos:      b.position(d.Decl),
+			Consts:   b.values(d.Consts),
+			Vars:     b.values(d.Vars),
+			Funcs:    b.funcs(d.Funcs),
+			Methods:  b.funcs(d.Methods),
+			Examples: b.getExamples(d.Name),
+		})
+	}
+	return result
+}
+
+func (b *builder) position(n ast.Node) gddo.Pos {
+	var p gddo.Pos
+	start := b.fset.Position(n.Pos())
+	i, ok := b.files[start.Filename]
+	if !ok {
+		return p
+	}
+	p.File = int16(i)
+	p.Line = int32(start.Line)
+	if end := b.fset.Position(n.End()); end.Filename == start.Filename {
+		p.N = uint16(end.Line - start.Line)
+	}
+	return p
+}
+
+var printConfig = printer.Config{Mode: printer.UseSpaces, Tabwidth: 4}
+
+func (b *builder) printDecl(decl ast.Decl) gddo.Code {
+	var buf bytes.Buffer
+	err := printConfig.Fprint(&buf, b.fset, decl)
+	if err != nil {
+		return gddo.Code{Text: err.Error()}
+	}
+	return gddo.Code{Text: buf.String()}
+}
+
+var exampleOutputRx = regexp.MustCompile(`(?i)//[[:space:]]*output:`)
+
+// getExamples returns the examples for the given identifier name. Examples are named
+// Example<name> or Example<name>_<suffix>, where suffix starts with a lower case letter.
+func (b *builder) getExamples(name string) []*gddo.Example {
+	var result []*gddo.Example
+	for _, e := range b.examples {
+		if !strings.HasPrefix(e.Name, name) {
+			continue
+		}
+		n := e.Name[len(name):]
+		if n != "" {
+			if i := strings.LastIndex(n, "_"); i != 0 {
+				continue
+			}
+			n = n[1:]
+			if n == "" || startsWithUppercase(n) {
+				continue
+			}
+			n = strings.ToUpper(n[:1]) + n[1:]
+		}
+
+		code, output := b.printExample(e)
+		play := ""
+		if e.Play != nil {
+			var buf bytes.Buffer
+			if err := format.Node(&buf, b.fset, e.Play); err != nil {
+				play = err.Error()
+			} else {
+				play = buf.String()
+			}
+		}
+
+		result = append(result, &gddo.Example{
+			Name:   n,
+			Doc:    e.Doc,
+			Code:   code,
+			Output: output,
+			Play:   play,
+		})
+	}
+	return result
+}
+
+func (b *builder) printExample(e *doc.Example) (gddo.Code, string) {
+	output := e.Output
+
+	var n interface{}
+	if _, ok := e.Code.(*ast.File); ok {
+		n = e.Play
+	} else {
+		n = &printer.CommentedNode{Node: e.Code, Comments: e.Comments}
+	}
+	var buf bytes.Buffer
+	err := printConfig.Fprint(&buf, b.fset, n)
+	if err != nil {
+		return gddo.Code{Text: err.Error()}, output
+	}
+
+	code := buf.Bytes()
+	if i := len(code); i >= 2 && code[0] == '{' && code[i-1] == '}' {
+		// Remove surrounding braces of a function body and unindent.
+		code = code[1 : i-1]
+		code = bytes.ReplaceAll(code, []byte("\n    "), []byte("\n"))
+		// Remove output comment.
+		if j := exampleOutputRx.FindIndex(code); j != nil {
+			code = bytes.TrimSpace(code[:j[0]])
+		}
+	} else {
+		// Drop output, as the output comment will appear in the code.
+		output = ""
+	}
+	return gddo.Code{Text: string(code)}, output
+}
+
+func startsWithUppercase(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r)
+}
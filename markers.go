@@ -0,0 +1,216 @@
+package goreadme
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// markerRx matches goreadme markers. The first group is the section name, which is empty for the
+// markers of the whole generated content, and the second group is either "start" or "end".
+var markerRx = regexp.MustCompile(`<!-- goreadme:(?:([a-z]+):)?(start|end) -->`)
+
+// multiNewLinesRx matches empty lines that are left after removing markers.
+var multiNewLinesRx = regexp.MustCompile(`\n{3,}`)
+
+// marker is a goreadme marker location in a text.
+type marker struct {
+	section    string
+	start      bool
+	begin, end int
+}
+
+// fenceRx matches the opening line of a fenced code block. The group is the fence.
+var fenceRx = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// findMarkers returns the goreadme markers in text. Markers in fenced code blocks and in inline
+// code spans are part of the text and are ignored.
+func findMarkers(text []byte) []marker {
+	var markers []marker
+	code := codeRanges(text)
+	for _, m := range markerRx.FindAllSubmatchIndex(text, -1) {
+		for len(code) > 0 && code[0][1] <= m[0] {
+			code = code[1:]
+		}
+		if len(code) > 0 && code[0][0] <= m[0] {
+			continue
+		}
+		mk := marker{begin: m[0], end: m[1], start: string(text[m[4]:m[5]]) == "start"}
+		if m[2] >= 0 {
+			mk.section = string(text[m[2]:m[3]])
+		}
+		markers = append(markers, mk)
+	}
+	return markers
+}
+
+// codeRanges returns the sorted ranges of the fenced code blocks and the inline code spans of a
+// markdown text.
+func codeRanges(text []byte) [][2]int {
+	var (
+		ranges [][2]int
+		fence  []byte // The opening fence of the current code block.
+		start  int    // The start of the current code block, or of the current text.
+	)
+	for pos := 0; pos < len(text); {
+		end := bytes.IndexByte(text[pos:], '\n') + pos + 1
+		if end == pos {
+			end = len(text)
+		}
+		line := text[pos:end]
+		switch {
+		case fence == nil:
+			if m := fenceRx.FindSubmatch(line); m != nil {
+				ranges = append(ranges, codeSpans(text[start:pos], start)...)
+				fence, start = m[1], pos
+			}
+		case isClosingFence(line, fence):
+			ranges = append(ranges, [2]int{start, end})
+			fence, start = nil, end
+		}
+		pos = end
+	}
+	if fence != nil {
+		// An unclosed code block continues to the end of the text.
+		return append(ranges, [2]int{start, len(text)})
+	}
+	return append(ranges, codeSpans(text[start:], start)...)
+}
+
+// isClosingFence returns true if the line closes a code block that was opened with fence.
+func isClosingFence(line, fence []byte) bool {
+	line = bytes.TrimLeft(line, " ")
+	n := len(line) - len(bytes.TrimLeft(line, string(fence[:1])))
+	return n >= len(fence) && len(bytes.TrimSpace(line[n:])) == 0
+}
+
+// codeSpans returns the ranges of the inline code spans in a markdown text without code blocks.
+// The ranges are shifted by offset. A code span starts with a backtick string and ends with a
+// backtick string of the same length in the same paragraph.
+func codeSpans(text []byte, offset int) [][2]int {
+	var ranges [][2]int
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		n := backticks(text[i:])
+		end := closingBackticks(text[i+n:], n)
+		if end < 0 {
+			i += n
+			continue
+		}
+		end += i + n
+		ranges = append(ranges, [2]int{offset + i, offset + end})
+		i = end
+	}
+	return ranges
+}
+
+// closingBackticks returns the end of the first backtick string of length n in the paragraph that
+// text starts in, or -1 if there is none.
+func closingBackticks(text []byte, n int) int {
+	if p := bytes.Index(text, []byte("\n\n")); p >= 0 {
+		text = text[:p]
+	}
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		m := backticks(text[i:])
+		if m == n {
+			return i + m
+		}
+		i += m
+	}
+	return -1
+}
+
+// backticks returns the length of the backtick string that text starts with.
+func backticks(text []byte) int {
+	return len(text) - len(bytes.TrimLeft(text, "`"))
+}
+
+// markerPairs returns the start and end markers of each section in text.
+func markerPairs(text []byte) ([][2]marker, error) {
+	var (
+		pairs [][2]marker
+		open  *marker
+	)
+	for _, m := range findMarkers(text) {
+		m := m
+		switch {
+		case m.start && open != nil:
+			return nil, errors.Errorf("goreadme marker %q starts inside %q", m.section, open.section)
+		case m.start:
+			open = &m
+		case open == nil || open.section != m.section:
+			return nil, errors.Errorf("goreadme marker %q ends without start", m.section)
+		default:
+			pairs = append(pairs, [2]marker{*open, m})
+			open = nil
+		}
+	}
+	if open != nil {
+		return nil, errors.Errorf("goreadme marker %q is not closed", open.section)
+	}
+	return pairs, nil
+}
+
+// generatedSections returns the content of each named section in the generated README, and the
+// whole generated content without the section markers, under the empty name.
+func generatedSections(generated []byte) (map[string][]byte, error) {
+	pairs, err := markerPairs(generated)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid generated README")
+	}
+	sections := make(map[string][]byte)
+	var whole []byte
+	last := 0
+	for _, p := range pairs {
+		sections[p[0].section] = trimNewLines(generated[p[0].end:p[1].begin])
+		whole = append(whole, generated[last:p[0].begin]...)
+		whole = append(whole, generated[p[0].end:p[1].begin]...)
+		last = p[1].end
+	}
+	whole = append(whole, generated[last:]...)
+	sections[""] = trimNewLines(multiNewLinesRx.ReplaceAll(whole, []byte("\n\n")))
+	return sections, nil
+}
+
+// replaceMarked replaces the content between goreadme markers in existing with the matching
+// content from the generated README. Text outside the markers is kept as is. Sections that do not
+// exist in the generated README are left empty.
+func replaceMarked(existing, generated []byte) ([]byte, error) {
+	pairs, err := markerPairs(existing)
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, errors.New("no goreadme markers found")
+	}
+	sections, err := generatedSections(generated)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, p := range pairs {
+		out.Write(existing[last:p[0].end])
+		out.WriteByte('\n')
+		if content := sections[p[0].section]; len(content) > 0 {
+			out.Write(content)
+			out.WriteByte('\n')
+		}
+		last = p[1].begin
+	}
+	out.Write(existing[last:])
+	return out.Bytes(), nil
+}
+
+func trimNewLines(b []byte) []byte {
+	return bytes.Trim(b, "\n")
+}
@@ -0,0 +1,177 @@
+package goreadme
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	const dir = "./testdata/pkg19_markers"
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name: "whole",
+			existing: `Hand written header.
+<!-- goreadme:start -->
+stale content
+<!-- goreadme:end -->
+## Contributors
+
+Hand written footer.
+`,
+			want: `Hand written header.
+<!-- goreadme:start -->
+# pkg19
+
+[![GoDoc](https://pkg.go.dev/badge/pkgsite/pkg.svg)](https://pkg.go.dev/pkg19)
+
+Package pkg19 tests wrapping the generated sections with goreadme markers.
+
+## Types
+
+### type [Type](/pkg.go#L5)
+
+` + "```go" + `
+type Type struct{ ... }
+` + "```" + `
+
+Type is a type.
+
+## Examples
+
+` + "```go" + `
+fmt.Println("hello")
+` + "```" + `
+
+ Output:
+
+` + "```" + `
+hello
+` + "```" + `
+<!-- goreadme:end -->
+## Contributors
+
+Hand written footer.
+`,
+		},
+		{
+			name: "sections",
+			existing: `# Hand Written Title
+
+<!-- goreadme:badges:start --><!-- goreadme:badges:end -->
+
+## Install
+
+	go get pkg19
+
+<!-- goreadme:doc:start -->
+stale doc
+<!-- goreadme:doc:end -->
+
+<!-- goreadme:functions:start -->
+stale functions
+<!-- goreadme:functions:end -->
+`,
+			want: `# Hand Written Title
+
+<!-- goreadme:badges:start -->
+[![GoDoc](https://pkg.go.dev/badge/pkgsite/pkg.svg)](https://pkg.go.dev/pkg19)
+<!-- goreadme:badges:end -->
+
+## Install
+
+	go get pkg19
+
+<!-- goreadme:doc:start -->
+Package pkg19 tests wrapping the generated sections with goreadme markers.
+<!-- goreadme:doc:end -->
+
+<!-- goreadme:functions:start -->
+<!-- goreadme:functions:end -->
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buf := bytes.NewBuffer(nil)
+			err := gr.WithConfig(loadConfig(t, dir)).Update(context.Background(), dir, strings.NewReader(tt.existing), buf)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestUpdateInvalidMarkers(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"no markers",
+		"<!-- goreadme:start -->",
+		"<!-- goreadme:end -->",
+		"<!-- goreadme:doc:start --><!-- goreadme:types:end -->",
+		"<!-- goreadme:start --><!-- goreadme:doc:start --><!-- goreadme:doc:end --><!-- goreadme:end -->",
+	}
+
+	for _, existing := range tests {
+		_, err := replaceMarked([]byte(existing), []byte("generated"))
+		assert.Error(t, err, existing)
+	}
+}
+
+func TestReplaceMarkedIgnoresCode(t *testing.T) {
+	t.Parallel()
+
+	const generated = "<!-- goreadme:doc:start -->\nUse `<!-- goreadme:start -->` markers.\n" +
+		"```\n<!-- goreadme:types:end -->\n```\n<!-- goreadme:doc:end -->\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name:     "code span",
+			existing: "Write `<!-- goreadme:doc:start -->`.\n<!-- goreadme:doc:start -->\nstale\n<!-- goreadme:doc:end -->\n",
+			want:     "Write `<!-- goreadme:doc:start -->`.\n<!-- goreadme:doc:start -->\nUse `<!-- goreadme:start -->` markers.\n```\n<!-- goreadme:types:end -->\n```\n<!-- goreadme:doc:end -->\n",
+		},
+		{
+			name:     "double backticks code span",
+			existing: "Write ``<!-- goreadme:end --> ` ``.\n<!-- goreadme:doc:start --><!-- goreadme:doc:end -->",
+			want:     "Write ``<!-- goreadme:end --> ` ``.\n<!-- goreadme:doc:start -->\nUse `<!-- goreadme:start -->` markers.\n```\n<!-- goreadme:types:end -->\n```\n<!-- goreadme:doc:end -->",
+		},
+		{
+			name:     "fenced code",
+			existing: "~~~~md\n<!-- goreadme:start -->\n~~~\n~~~~\n<!-- goreadme:doc:start --><!-- goreadme:doc:end -->",
+			want:     "~~~~md\n<!-- goreadme:start -->\n~~~\n~~~~\n<!-- goreadme:doc:start -->\nUse `<!-- goreadme:start -->` markers.\n```\n<!-- goreadme:types:end -->\n```\n<!-- goreadme:doc:end -->",
+		},
+		{
+			name:     "unmatched backtick",
+			existing: "A ` backtick.\n\n<!-- goreadme:doc:start --><!-- goreadme:doc:end -->",
+			want:     "A ` backtick.\n\n<!-- goreadme:doc:start -->\nUse `<!-- goreadme:start -->` markers.\n```\n<!-- goreadme:types:end -->\n```\n<!-- goreadme:doc:end -->",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := replaceMarked([]byte(tt.existing), []byte(generated))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package goreadme
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/golang/gddo/doc"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/module"
+	modzip "golang.org/x/mod/zip"
+)
+
+// Source provides Go packages documentation. A source can be set with GoReadme.WithSource. By
+// default, filesystem paths are loaded with LocalSource, and import paths are fetched from their
+// version control service.
+type Source interface {
+	// Package returns the documentation of the package with the given name.
+	Package(ctx context.Context, name string) (*doc.Package, error)
+	// Subdirectories returns the directories of the package with the given name, relative to the
+	// package directory. Sub packages are named by joining the package name and the sub directory
+	// with a slash.
+	Subdirectories(ctx context.Context, name string) ([]string, error)
+}
+
+// dirSource is implemented by sources that have the source files of their packages in a local
+// directory. Dir returns an empty directory if the source files of a package are not available.
+type dirSource interface {
+	Dir(ctx context.Context, name string) (string, error)
+}
+
+// LocalSource loads packages from the local filesystem. Package names are directory paths.
+type LocalSource struct{}
+
+// Dir returns the package directory, which is the package name.
+func (LocalSource) Dir(ctx context.Context, name string) (string, error) {
+	return name, nil
+}
+
+func (LocalSource) Package(ctx context.Context, name string) (*doc.Package, error) {
+	return loadLocal(ctx, name)
+}
+
+func (LocalSource) Subdirectories(ctx context.Context, name string) ([]string, error) {
+	return localSubdirs(name)
+}
+
+// ZipSource loads packages from a module zip file, in the format that is served by Go module
+// proxies. Package names are import paths in the module. The zip file is extracted to a temporary
+// directory on first use, which is removed by Close.
+type ZipSource struct {
+	file string
+
+	once sync.Once
+	mod  *extractedModule
+	err  error
+}
+
+// NewZipSource returns a source for the module zip file in the given path.
+func NewZipSource(file string) *ZipSource {
+	return &ZipSource{file: file}
+}
+
+func (s *ZipSource) Package(ctx context.Context, name string) (*doc.Package, error) {
+	m, err := s.extract()
+	if err != nil {
+		return nil, err
+	}
+	return m.Package(ctx, name)
+}
+
+// Dir returns the directory of the package in the extracted module.
+func (s *ZipSource) Dir(ctx context.Context, name string) (string, error) {
+	m, err := s.extract()
+	if err != nil {
+		return "", err
+	}
+	return m.path(name)
+}
+
+func (s *ZipSource) Subdirectories(ctx context.Context, name string) ([]string, error) {
+	m, err := s.extract()
+	if err != nil {
+		return nil, err
+	}
+	return m.Subdirectories(name)
+}
+
+// Close removes the extracted module files.
+func (s *ZipSource) Close() error {
+	if s.mod == nil {
+		return nil
+	}
+	return os.RemoveAll(s.mod.root)
+}
+
+func (s *ZipSource) extract() (*extractedModule, error) {
+	s.once.Do(func() {
+		var v module.Version
+		v, s.err = zipModuleVersion(s.file)
+		if s.err != nil {
+			return
+		}
+		var root string
+		root, s.err = os.MkdirTemp("", "goreadme")
+		if s.err != nil {
+			return
+		}
+		s.mod, s.err = extractModule(root, v, s.file)
+		if s.err != nil {
+			// Close does not know about the directory without the module.
+			os.RemoveAll(root)
+		}
+	})
+	return s.mod, s.err
+}
+
+// zipModuleVersion returns the module path and version of a module zip file. All the files in a
+// module zip file are prefixed with "<module path>@<version>/".
+func zipModuleVersion(file string) (module.Version, error) {
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		return module.Version{}, errors.Wrapf(err, "failed opening %s", file)
+	}
+	defer z.Close()
+	if len(z.File) == 0 {
+		return module.Version{}, errors.Errorf("empty module zip file %s", file)
+	}
+	name := z.File[0].Name
+	at := strings.Index(name, "@")
+	if at < 0 {
+		return module.Version{}, errors.Errorf("invalid module zip file %s: missing version prefix", file)
+	}
+	slash := strings.Index(name[at:], "/")
+	if slash < 0 {
+		return module.Version{}, errors.Errorf("invalid module zip file %s: missing version prefix", file)
+	}
+	return module.Version{Path: name[:at], Version: name[at+1 : at+slash]}, nil
+}
+
+// ProxySource loads packages from a Go module proxy, using the GOPROXY protocol. Package names are
+// import paths. Modules are downloaded and extracted to a temporary directory on first use, which
+// is removed by Close.
+type ProxySource struct {
+	client  *http.Client
+	url     string
+	version string
+
+	mu      sync.Mutex
+	root    string
+	modules []*extractedModule
+}
+
+// NewProxySource returns a source that downloads modules from the module proxy in the given URL,
+// for example https://proxy.golang.org. version is the module version to load, if empty the latest
+// version is loaded.
+func NewProxySource(client *http.Client, url, version string) *ProxySource {
+	return &ProxySource{
+		client:  client,
+		url:     strings.TrimSuffix(url, "/"),
+		version: version,
+	}
+}
+
+func (s *ProxySource) Package(ctx context.Context, name string) (*doc.Package, error) {
+	m, err := s.module(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return m.Package(ctx, name)
+}
+
+// Dir returns the directory of the package in the downloaded module.
+func (s *ProxySource) Dir(ctx context.Context, name string) (string, error) {
+	m, err := s.module(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return m.path(name)
+}
+
+func (s *ProxySource) Subdirectories(ctx context.Context, name string) ([]string, error) {
+	m, err := s.module(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return m.Subdirectories(name)
+}
+
+// Close removes the downloaded module files.
+func (s *ProxySource) Close() error {
+	if s.root == "" {
+		return nil
+	}
+	return os.RemoveAll(s.root)
+}
+
+// module returns the module that contains the given import path. The module path is the longest
+// prefix of the import path that the proxy knows about.
+func (s *ProxySource) module(ctx context.Context, importPath string) (*extractedModule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.modules {
+		if m.contains(importPath) {
+			return m, nil
+		}
+	}
+
+	if s.root == "" {
+		root, err := os.MkdirTemp("", "goreadme")
+		if err != nil {
+			return nil, err
+		}
+		s.root = root
+	}
+
+	for modPath := importPath; modPath != "."; modPath = path.Dir(modPath) {
+		version, err := s.resolve(ctx, modPath)
+		if err != nil {
+			return nil, err
+		}
+		if version == "" {
+			continue // Not a module.
+		}
+		m, err := s.download(ctx, module.Version{Path: modPath, Version: version})
+		if err != nil {
+			return nil, err
+		}
+		s.modules = append(s.modules, m)
+		return m, nil
+	}
+	return nil, errors.Errorf("module of %s not found in %s", importPath, s.url)
+}
+
+// resolve returns the version of the given module path, or an empty string if the proxy does not
+// know this module.
+func (s *ProxySource) resolve(ctx context.Context, path string) (string, error) {
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return "", nil // Not a valid module path.
+	}
+	query := "@latest"
+	if s.version != "" {
+		v, err := module.EscapeVersion(s.version)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid version %s", s.version)
+		}
+		query = "@v/" + v + ".info"
+	}
+	resp, err := s.get(ctx, escaped+"/"+query)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return "", nil
+	default:
+		return "", errors.Errorf("failed resolving %s: %s", path, resp.Status)
+	}
+	var info struct{ Version string }
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", errors.Wrapf(err, "failed decoding %s version info", path)
+	}
+	return info.Version, nil
+}
+
+func (s *ProxySource) download(ctx context.Context, v module.Version) (*extractedModule, error) {
+	escaped, err := module.EscapePath(v.Path)
+	if err != nil {
+		return nil, err
+	}
+	version, err := module.EscapeVersion(v.Version)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.get(ctx, escaped+"/@v/"+version+".zip")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed downloading %s: %s", v, resp.Status)
+	}
+
+	f, err := os.CreateTemp(s.root, "*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return nil, errors.Wrapf(err, "failed downloading %s", v)
+	}
+	return extractModule(s.root, v, f.Name())
+}
+
+func (s *ProxySource) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
+
+// extractedModule is a module that was extracted from a module zip file.
+type extractedModule struct {
+	module.Version
+	// root is the directory that contains the extracted module.
+	root string
+	// dir is the directory of the module root package.
+	dir string
+}
+
+func extractModule(root string, v module.Version, file string) (*extractedModule, error) {
+	escaped, err := module.EscapePath(v.Path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(root, filepath.FromSlash(escaped)+"@"+v.Version)
+	if err := modzip.Unzip(dir, v, file); err != nil {
+		return nil, errors.Wrapf(err, "failed extracting %s", v)
+	}
+	return &extractedModule{Version: v, root: root, dir: dir}, nil
+}
+
+// contains returns true if the import path is in the module.
+func (m *extractedModule) contains(importPath string) bool {
+	return importPath == m.Path || strings.HasPrefix(importPath, m.Path+"/")
+}
+
+// path returns the directory of the package with the given import path.
+func (m *extractedModule) path(importPath string) (string, error) {
+	if !m.contains(importPath) {
+		return "", errors.Errorf("package %s is not in module %s", importPath, m.Path)
+	}
+	rel := strings.TrimPrefix(importPath, m.Path)
+	return filepath.Join(m.dir, filepath.FromSlash(rel)), nil
+}
+
+func (m *extractedModule) Package(ctx context.Context, importPath string) (*doc.Package, error) {
+	dir, err := m.path(importPath)
+	if err != nil {
+		return nil, err
+	}
+	p, err := loadLocal(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	// The extracted module might not have a go.mod file.
+	p.ImportPath = importPath
+	p.ProjectRoot = m.Path
+	return p, nil
+}
+
+func (m *extractedModule) Subdirectories(importPath string) ([]string, error) {
+	dir, err := m.path(importPath)
+	if err != nil {
+		return nil, err
+	}
+	return localSubdirs(dir)
+}
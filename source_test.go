@@ -0,0 +1,170 @@
+package goreadme
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/gddo/doc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/module"
+	modzip "golang.org/x/mod/zip"
+)
+
+// testModule is the module version that testdata/pkg2_recursive is zipped as.
+var testModule = module.Version{Path: "example.com/pkg2", Version: "v1.0.0"}
+
+func TestZipSource(t *testing.T) {
+	t.Parallel()
+
+	src := NewZipSource(writeTestModuleZip(t))
+	defer src.Close()
+
+	buf := bytes.NewBuffer(nil)
+	cfg := loadConfig(t, "testdata/pkg2_recursive")
+	err := New(nil).WithSource(src).WithConfig(cfg).Create(context.Background(), testModule.Path, buf)
+	require.NoError(t, err)
+	assertReadme(t, "testdata/pkg2_recursive", buf.String())
+}
+
+func TestZipSourceInvalid(t *testing.T) {
+	// The temporary directory of the extracted module is in TMPDIR.
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// The module has files whose names differ only in case, which fails the extraction.
+	path := filepath.Join(t.TempDir(), "module.zip")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for _, name := range []string{"go.mod", "a.go", "A.go"} {
+		_, err := w.Create(testModule.String() + "/" + name)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	src := NewZipSource(path)
+	_, err = src.Package(context.Background(), testModule.Path)
+	assert.Error(t, err)
+	require.NoError(t, src.Close())
+
+	// The temporary directory was removed.
+	entries, err := os.ReadDir(tmp)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestProxySource(t *testing.T) {
+	t.Parallel()
+
+	zip, err := os.ReadFile(writeTestModuleZip(t))
+	require.NoError(t, err)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + testModule.Path + "/@latest":
+			w.Write([]byte(`{"Version":"` + testModule.Version + `"}`))
+		case "/" + testModule.Path + "/@v/" + testModule.Version + ".zip":
+			w.Write(zip)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+
+	src := NewProxySource(proxy.Client(), proxy.URL, "")
+	defer src.Close()
+
+	buf := bytes.NewBuffer(nil)
+	cfg := loadConfig(t, "testdata/pkg2_recursive")
+	err = New(nil).WithSource(src).WithConfig(cfg).Create(context.Background(), testModule.Path+"/subpkg1", buf)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "# subpkg1\n"), buf.String())
+	assert.Contains(t, buf.String(), "* [subsubpkg](./subsubpkg): Package subsubpkg is the sub-subpackage")
+}
+
+// TestRef loads a remote package at a ref from the module proxy in GOPROXY. It can't run in
+// parallel, as it sets an environment variable.
+func TestRef(t *testing.T) {
+	zip, err := os.ReadFile(writeTestModuleZip(t))
+	require.NoError(t, err)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + testModule.Path + "/@v/" + testModule.Version + ".info":
+			w.Write([]byte(`{"Version":"` + testModule.Version + `"}`))
+		case "/" + testModule.Path + "/@v/" + testModule.Version + ".zip":
+			w.Write(zip)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+	t.Setenv("GOPROXY", proxy.URL+",direct")
+
+	buf := bytes.NewBuffer(nil)
+	cfg := loadConfig(t, "testdata/pkg2_recursive")
+	cfg.Ref = testModule.Version
+	err = New(proxy.Client()).WithConfig(cfg).Create(context.Background(), testModule.Path, buf)
+	require.NoError(t, err)
+	assertReadme(t, "testdata/pkg2_recursive", buf.String())
+}
+
+func TestCustomSource(t *testing.T) {
+	t.Parallel()
+
+	src := fakeSource{
+		"example.com/fake": {
+			Name:           "fake",
+			ImportPath:     "example.com/fake",
+			Doc:            "Package fake is served from memory.",
+			Subdirectories: []string{"sub"},
+		},
+		"example.com/fake/sub": {
+			Name:     "sub",
+			Synopsis: "Package sub is a sub package.",
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := New(nil).WithSource(src).Create(context.Background(), "example.com/fake", buf)
+	require.NoError(t, err)
+	assert.Equal(t, "# fake\n\nPackage fake is served from memory.\n\n## Packages\n\n* [sub](./sub): Package sub is a sub package.\n", buf.String())
+}
+
+// fakeSource is an in-memory package source.
+type fakeSource map[string]*doc.Package
+
+func (s fakeSource) Package(_ context.Context, name string) (*doc.Package, error) {
+	p, ok := s[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return p, nil
+}
+
+func (s fakeSource) Subdirectories(ctx context.Context, name string) ([]string, error) {
+	p, err := s.Package(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.Subdirectories, nil
+}
+
+func writeTestModuleZip(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "module.zip")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, modzip.CreateFromDir(f, testModule, "testdata/pkg2_recursive"))
+	return path
+}
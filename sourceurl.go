@@ -0,0 +1,185 @@
+package goreadme
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/golang/gddo/doc"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// sourceURLPresets are the source URL patterns of the known hosting services, by name.
+var sourceURLPresets = map[string]string{
+	"github":    "https://{host}/{repo}/blob/{ref}/{path}#L{line}",
+	"gitlab":    "https://{host}/{repo}/-/blob/{ref}/{path}#L{line}",
+	"bitbucket": "https://{host}/{repo}/src/{ref}/{path}#lines-{line}",
+	"gitea":     "https://{host}/{repo}/src/{refType}/{ref}/{path}#L{line}",
+	"sourcehut": "https://{host}/{repo}/tree/{ref}/item/{path}#L{line}",
+}
+
+// hostPresets are the source URL presets of the hosts of the known hosting services.
+var hostPresets = map[string]string{
+	"github.com":    "github",
+	"gitlab.com":    "gitlab",
+	"bitbucket.org": "bitbucket",
+	"gitea.com":     "gitea",
+	"codeberg.org":  "gitea",
+	"git.sr.ht":     "sourcehut",
+}
+
+// twoLevelPresets are the presets of the hosting services in which repositories are always two
+// path elements under the host, such as `github.com/user/repo`. In other services, such as GitLab,
+// where projects can be nested in subgroups, the repository is the module path.
+var twoLevelPresets = map[string]bool{
+	"github":    true,
+	"bitbucket": true,
+	"gitea":     true,
+	"sourcehut": true,
+}
+
+// sourceURLs creates links to the files of a repository from a source URL pattern.
+type sourceURLs struct {
+	pattern string
+	host    string
+	repo    string
+	ref     string
+	refType string
+	// dir is the path of the package directory in the repository.
+	dir string
+}
+
+// newSourceURLs returns the source URLs of the repository of the package. The pattern is the
+// configured source URL pattern or preset, or the preset of the host of the import path. It
+// returns false if the pattern is not known.
+func newSourceURLs(p *doc.Package, cfg Config) (sourceURLs, bool) {
+	parts := strings.SplitN(p.ImportPath, "/", 4)
+	if len(parts) < 3 {
+		return sourceURLs{}, false
+	}
+	preset := cfg.SourceURLPattern
+	if preset == "" {
+		preset = hostPresets[parts[0]]
+	}
+	pattern := preset
+	if presetPattern, ok := sourceURLPresets[preset]; ok {
+		pattern = presetPattern
+	}
+	if pattern == "" {
+		return sourceURLs{}, false
+	}
+	root := strings.Join(parts[:3], "/")
+	if modRoot := moduleRoot(p); !twoLevelPresets[preset] && modRoot != "" {
+		root = modRoot
+	}
+	ref, refType := sourceRef(cfg)
+	return sourceURLs{
+		pattern: pattern,
+		host:    parts[0],
+		repo:    strings.TrimPrefix(root, parts[0]+"/"),
+		ref:     ref,
+		refType: refType,
+		dir:     strings.TrimPrefix(strings.TrimPrefix(p.ImportPath, root), "/"),
+	}, true
+}
+
+// moduleRoot returns the module path of the package without the major version suffix, if the
+// package import path is in it and it has a repository path after the host. Otherwise, for example
+// when the import path is overridden, it returns an empty string.
+func moduleRoot(p *doc.Package) string {
+	root, _, ok := module.SplitPathVersion(p.ProjectRoot)
+	if !ok || strings.Count(root, "/") < 2 {
+		return ""
+	}
+	if p.ImportPath != root && !strings.HasPrefix(p.ImportPath, root+"/") {
+		return ""
+	}
+	return root
+}
+
+// file returns the URL of a file in the repository, given its path relative to the repository
+// root.
+func (s sourceURLs) file(filePath string) string {
+	base, _ := s.split()
+	return strings.NewReplacer(
+		"{host}", s.host,
+		"{repo}", s.repo,
+		"{ref}", s.ref,
+		"{refType}", s.refType,
+		"{path}", filePath,
+	).Replace(base)
+}
+
+// local returns the URL of a path that is relative to the package directory, such as `./docs`.
+func (s sourceURLs) local(localPath string) string {
+	p := path.Join(s.dir, localPath)
+	if p == "." {
+		p = ""
+	}
+	return s.file(p)
+}
+
+// lineFmt returns the format of a line link, with the file URL and the line number.
+func (s sourceURLs) lineFmt() string {
+	_, fragment := s.split()
+	return "%s" + strings.Replace(strings.ReplaceAll(fragment, "%", "%%"), "{line}", "%d", 1)
+}
+
+// split splits the pattern to the file URL pattern and the fragment with the line number.
+func (s sourceURLs) split() (base, fragment string) {
+	i := strings.LastIndex(s.pattern, "#")
+	if i < 0 || !strings.Contains(s.pattern[i:], "{line}") {
+		return s.pattern, ""
+	}
+	return s.pattern[:i], s.pattern[i:]
+}
+
+var commitRx = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// sourceRef returns the ref of the source links, which is the configured ref or the branch, and
+// its type: branch, tag or commit. Semantic versions are tags, and pseudo-versions are replaced by
+// their commit. Other refs that are not commit hashes are considered to be branches.
+func sourceRef(cfg Config) (ref, refType string) {
+	switch ref := cfg.Ref; {
+	case ref == "":
+		return branch(cfg), "branch"
+	case module.IsPseudoVersion(ref):
+		rev, err := module.PseudoVersionRev(ref)
+		if err != nil {
+			return ref, "tag"
+		}
+		return rev, "commit"
+	case semver.IsValid(ref):
+		return ref, "tag"
+	case commitRx.MatchString(ref):
+		return ref, "commit"
+	default:
+		return ref, "branch"
+	}
+}
+
+// setSourceURLs sets the URLs of the package files and the format of line links, which are used
+// in the source links, according to the source URL pattern. Files that already have a URL are not
+// changed, unless a source URL pattern is configured.
+func setSourceURLs(p *doc.Package, cfg Config) {
+	if cfg.Branch == "" && cfg.Ref == "" && cfg.SourceURLPattern == "" {
+		return
+	}
+	s, ok := newSourceURLs(p, cfg)
+	if !ok {
+		return
+	}
+	override := cfg.SourceURLPattern != ""
+	files := make([]*doc.File, len(p.Files))
+	for i, f := range p.Files {
+		if f.URL == "" || override {
+			f = &doc.File{Name: f.Name, URL: s.file(path.Join(s.dir, f.Name))}
+		}
+		files[i] = f
+	}
+	p.Files = files
+	if p.LineFmt == "" || override {
+		p.LineFmt = s.lineFmt()
+	}
+}
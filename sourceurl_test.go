@@ -0,0 +1,122 @@
+package goreadme
+
+import (
+	"testing"
+
+	"github.com/golang/gddo/doc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSourceURLs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		importPath string
+		moduleRoot string
+		cfg        Config
+		wantURL    string
+		wantLine   string
+	}{
+		{
+			name:       "github branch",
+			importPath: "github.com/user/repo/pkg",
+			cfg:        Config{Branch: "main"},
+			wantURL:    "https://github.com/user/repo/blob/main/pkg/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "ref overrides branch",
+			importPath: "github.com/user/repo",
+			cfg:        Config{Branch: "main", Ref: "v1.2.3"},
+			wantURL:    "https://github.com/user/repo/blob/v1.2.3/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "bitbucket",
+			importPath: "bitbucket.org/user/repo",
+			cfg:        Config{Branch: "main"},
+			wantURL:    "https://bitbucket.org/user/repo/src/main/pkg.go",
+			wantLine:   "%s#lines-%d",
+		},
+		{
+			name:       "sourcehut preset",
+			importPath: "git.example.com/~user/repo",
+			cfg:        Config{Ref: "abc123", SourceURLPattern: "sourcehut"},
+			wantURL:    "https://git.example.com/~user/repo/tree/abc123/item/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "custom pattern",
+			importPath: "example.com/user/repo",
+			cfg:        Config{SourceURLPattern: "https://src.example.com/{repo}/{path}?at={ref}#line-{line}"},
+			wantURL:    "https://src.example.com/user/repo/pkg.go?at=master",
+			wantLine:   "%s#line-%d",
+		},
+		{
+			name:       "gitlab subgroup",
+			importPath: "gitlab.com/group/sub/repo/pkg",
+			moduleRoot: "gitlab.com/group/sub/repo/v2",
+			cfg:        Config{Branch: "main"},
+			wantURL:    "https://gitlab.com/group/sub/repo/-/blob/main/pkg/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "gitlab with import path outside module",
+			importPath: "example.com/group/sub/repo",
+			moduleRoot: "example.com/vanity",
+			cfg:        Config{Branch: "main", SourceURLPattern: "gitlab"},
+			wantURL:    "https://example.com/group/sub/-/blob/main/repo/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "github ignores module root",
+			importPath: "github.com/user/repo/tools/pkg",
+			moduleRoot: "github.com/user/repo/tools",
+			cfg:        Config{Branch: "main"},
+			wantURL:    "https://github.com/user/repo/blob/main/tools/pkg/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "gitea branch",
+			importPath: "codeberg.org/user/repo",
+			cfg:        Config{Branch: "main"},
+			wantURL:    "https://codeberg.org/user/repo/src/branch/main/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "gitea tag",
+			importPath: "codeberg.org/user/repo",
+			cfg:        Config{Ref: "v1.2.3"},
+			wantURL:    "https://codeberg.org/user/repo/src/tag/v1.2.3/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "gitea commit",
+			importPath: "codeberg.org/user/repo",
+			cfg:        Config{Ref: "0123456789ab"},
+			wantURL:    "https://codeberg.org/user/repo/src/commit/0123456789ab/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "pseudo-version",
+			importPath: "codeberg.org/user/repo",
+			cfg:        Config{Ref: "v0.0.0-20200101000000-0123456789ab"},
+			wantURL:    "https://codeberg.org/user/repo/src/commit/0123456789ab/pkg.go",
+			wantLine:   "%s#L%d",
+		},
+		{
+			name:       "unknown host",
+			importPath: "example.com/user/repo",
+			cfg:        Config{Branch: "main"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &doc.Package{ImportPath: tt.importPath, ProjectRoot: tt.moduleRoot, Files: []*doc.File{{Name: "pkg.go"}}}
+			setSourceURLs(p, tt.cfg)
+			assert.Equal(t, tt.wantURL, p.Files[0].URL)
+			assert.Equal(t, tt.wantLine, p.LineFmt)
+		})
+	}
+}
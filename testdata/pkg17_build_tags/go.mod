@@ -0,0 +1,3 @@
+module example.com/pkg17
+
+go 1.13
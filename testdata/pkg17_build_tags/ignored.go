@@ -0,0 +1,6 @@
+//go:build ignore
+
+package pkg17
+
+// Ignored is defined in a file that is excluded by build constraints.
+func Ignored() {}
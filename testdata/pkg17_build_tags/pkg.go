@@ -0,0 +1,5 @@
+// Package pkg17 tests that files excluded by build constraints are not documented.
+package pkg17
+
+// Included is defined in a file without build constraints.
+func Included() {}
@@ -0,0 +1,3 @@
+module pkg18
+
+go 1.13
@@ -0,0 +1,11 @@
+// Package pkg18 tests overriding templates from a template directory.
+package pkg18
+
+// Func is a function.
+func Func() {}
+
+// Type is a type.
+type Type struct{}
+
+// Other is another type.
+type Other int
@@ -0,0 +1,3 @@
+module pkg19
+
+go 1.13
@@ -0,0 +1,5 @@
+// Package pkg19 tests wrapping the generated sections with goreadme markers.
+package pkg19
+
+// Type is a type.
+type Type struct{}
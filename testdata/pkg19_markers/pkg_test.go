@@ -0,0 +1,8 @@
+package pkg19
+
+import "fmt"
+
+func Example() {
+	fmt.Println("hello")
+	// Output: hello
+}
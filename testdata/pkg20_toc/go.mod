@@ -0,0 +1,3 @@
+module pkg20
+
+go 1.22
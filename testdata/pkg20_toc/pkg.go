@@ -0,0 +1,28 @@
+// Package pkg20 tests the table of contents.
+//
+// Usage
+//
+// Call New to create a Client.
+//
+// Types
+//
+// This heading has the same name as the generated types section.
+package pkg20
+
+// Client is a client.
+type Client struct{}
+
+// New returns a new client.
+func New() *Client { return &Client{} }
+
+// Close closes the client.
+func (c *Client) Close() error { return nil }
+
+// Server is a server.
+type Server struct{}
+
+// Close closes the server.
+func (s *Server) Close() error { return nil }
+
+// Run runs a server.
+func Run(s *Server) {}
@@ -0,0 +1,3 @@
+module pkg21
+
+go 1.22
@@ -0,0 +1,19 @@
+// Package pkg21 tests writing the API reference to a separate file.
+//
+// Run a Client to test links to the API reference file.
+package pkg21
+
+// Version is the package version.
+const Version = "1.0.0"
+
+// Default is the default client.
+var Default = &Client{}
+
+// Client is a client.
+type Client struct{}
+
+// Close closes the client.
+func (c *Client) Close() error { return nil }
+
+// Run runs a client.
+func Run(c *Client) {}
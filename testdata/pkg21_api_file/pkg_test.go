@@ -0,0 +1,8 @@
+package pkg21
+
+import "fmt"
+
+func Example() {
+	fmt.Println("hello")
+	// Output: hello
+}
@@ -0,0 +1,3 @@
+module pkg22
+
+go 1.22
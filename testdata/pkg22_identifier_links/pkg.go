@@ -0,0 +1,23 @@
+// Package pkg22 tests linking identifiers to their sections.
+//
+// Create a Client with Dial, and set its Config.Timeout field. Close the client with
+// Client.Close. The Config.Unknown field does not exist, and unexported is not linked. A word in
+// inline code, such as `Client`, is not linked.
+package pkg22
+
+// Config configures a client.
+type Config struct {
+	// Timeout in seconds.
+	Timeout int
+}
+
+// Client is a client.
+type Client struct{}
+
+// Close closes the Client.
+func (c *Client) Close() error { return nil }
+
+// Dial returns a new Client.
+func Dial(cfg Config) *Client { return &Client{} }
+
+func unexported() {}
@@ -0,0 +1,3 @@
+module pkg23
+
+go 1.22
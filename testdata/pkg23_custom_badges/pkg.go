@@ -0,0 +1,2 @@
+// Package pkg23 tests the built-in and custom badges, and their order.
+package pkg23
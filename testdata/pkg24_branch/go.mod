@@ -0,0 +1,3 @@
+module pkg24
+
+go 1.22
@@ -0,0 +1,5 @@
+// Package pkg24 tests badges and source links with a configured branch.
+package pkg24
+
+// Func is a function.
+func Func() {}
@@ -0,0 +1,4 @@
+// Tool is a command line tool.
+package main
+
+func main() {}
@@ -0,0 +1,3 @@
+module example.com/pkg25/v2
+
+go 1.22
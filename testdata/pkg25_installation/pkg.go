@@ -0,0 +1,2 @@
+// Package pkg25 tests the installation section of a library with a command.
+package pkg25
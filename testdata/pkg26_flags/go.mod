@@ -0,0 +1,3 @@
+module pkg26
+
+go 1.22
@@ -0,0 +1,47 @@
+// Package main tests the flags section of commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const nameFlag = "name"
+
+type config struct {
+	Verbose bool
+	Level   int
+}
+
+var cfg config
+
+var (
+	name    = flag.String(nameFlag, "world", "Name to greet.")
+	timeout = flag.Duration("timeout", 5*time.Second, "Timeout of the | greeting.")
+	prefix  = flag.String("prefix", "", "Prefix of the greeting.")
+	tags    listFlag
+)
+
+func init() {
+	flag.BoolVar(&cfg.Verbose, "verbose", false, "Print verbose output.")
+	flag.IntVar(&cfg.Level, "level", 1,
+		"Greeting level.")
+	flag.Var(&tags, "tags", "Comma separated tags.")
+	flag.Func("color", "Color of the greeting.", func(v string) error { return nil })
+	flag.BoolFunc("debug", "Print debug output.", func(string) error {
+		cfg.Verbose = true
+		return nil
+	})
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = strings.Split(v, ","); return nil }
+
+func main() {
+	flag.Parse()
+	fmt.Println(*prefix, "hello", *name, *timeout)
+}
@@ -0,0 +1,4 @@
+// Client requests greetings from the server.
+package main
+
+func main() {}
@@ -0,0 +1,4 @@
+// Server serves the greetings. It listens on the given address.
+package main
+
+func main() {}
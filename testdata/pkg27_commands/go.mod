@@ -0,0 +1,3 @@
+module example.com/pkg27
+
+go 1.22
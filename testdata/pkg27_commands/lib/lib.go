@@ -0,0 +1,2 @@
+// Package lib is a library of greetings.
+package lib
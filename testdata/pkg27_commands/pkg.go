@@ -0,0 +1,2 @@
+// Package pkg27 tests the commands and packages sections of the sub packages.
+package pkg27
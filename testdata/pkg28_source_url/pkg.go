@@ -0,0 +1,7 @@
+// Package pkg28 tests source links with a source URL pattern.
+//
+// The docs are in ./docs, and the (example) ./docs/example.md is linked with a title.
+package pkg28
+
+// Func is a function.
+func Func() {}
@@ -0,0 +1,6 @@
+package itest
+
+import "testing"
+
+// TestIntegration is in a directory without a package, only with tests.
+func TestIntegration(t *testing.T) {}
@@ -0,0 +1,58 @@
+// Package pkg has deprecated identifiers. Use Client instead of OldClient.
+package pkg
+
+// Modes of the client.
+const (
+	// Fast mode.
+	Fast = "fast"
+	// Slow mode.
+	//
+	// Deprecated: Use Fast.
+	Slow = "slow"
+)
+
+// DefaultTimeout is the timeout of requests.
+//
+// Deprecated: Set Client.Timeout instead.
+var DefaultTimeout = 10
+
+// Client sends requests.
+type Client struct {
+	// Addr is the server address.
+	Addr string
+	// Timeout of requests.
+	Timeout int
+	// Retries is ignored.
+	//
+	// Deprecated: Requests are not retried anymore.
+	Retries int
+}
+
+// New returns a new client.
+func New(addr string) *Client { return &Client{Addr: addr} }
+
+// Do sends a request.
+func (c *Client) Do(req string) error { return nil }
+
+// DoOld sends a request.
+//
+// Deprecated: Use Client.Do.
+func (c *Client) DoOld(req string) error { return nil }
+
+// OldClient sends requests.
+//
+// Deprecated: Use Client.
+type OldClient struct {
+	Addr string
+}
+
+// Do sends a request.
+func (c *OldClient) Do(req string) error { return nil }
+
+// Send sends a request with a new client.
+func Send(addr, req string) error { return New(addr).Do(req) }
+
+// SendOld sends a request.
+//
+// Deprecated: Use Send.
+func SendOld(req string) error { return nil }
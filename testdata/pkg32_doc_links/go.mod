@@ -0,0 +1,3 @@
+module example.com/pkg32
+
+go 1.22
@@ -0,0 +1,5 @@
+// Package engine is implemented in a directory with a different name.
+package engine
+
+// Run runs the engine.
+func Run() {}
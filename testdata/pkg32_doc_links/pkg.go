@@ -0,0 +1,22 @@
+// Package pkg32 tests resolving package names in doc links.
+//
+// A [Client] reads from an [io.Reader] into a [str.Builder], and runs the [engine.Run] function
+// of a package whose name is not its directory name. The [pkg32.Client] link uses the package
+// name, although the title is overridden.
+package pkg32
+
+import (
+	"io"
+	str "strings"
+
+	"example.com/pkg32/internal/impl"
+)
+
+// Client is a client.
+type Client struct {
+	r io.Reader
+	b str.Builder
+}
+
+// Run runs the client.
+func (c *Client) Run() { engine.Run() }
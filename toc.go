@@ -0,0 +1,93 @@
+package goreadme
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// tocPlaceholder is written by the templates where the table of contents should be inserted.
+const tocPlaceholder = "<!-- goreadme:toc -->"
+
+// defaultTOCDepth is the deepest heading level in the table of contents, when not configured.
+const defaultTOCDepth = 4
+
+var (
+	headingRx = regexp.MustCompile(`^(#{1,6}) +(.+?) *#*$`)
+	linkRx    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+)
+
+type heading struct {
+	level int
+	text  string
+	slug  string
+}
+
+// insertTOC replaces the table of contents placeholder in readme with a linked list of the
+// headings in the readme, from level 2 up to depth.
+func insertTOC(readme []byte, depth int) []byte {
+	if !bytes.Contains(readme, []byte(tocPlaceholder)) {
+		return readme
+	}
+	if depth <= 0 {
+		depth = defaultTOCDepth
+	}
+	var toc strings.Builder
+	for _, h := range headings(readme) {
+		if h.level < 2 || h.level > depth {
+			continue
+		}
+		fmt.Fprintf(&toc, "%s- [%s](#%s)\n", strings.Repeat("  ", h.level-2), h.text, h.slug)
+	}
+	return bytes.Replace(readme, []byte(tocPlaceholder), []byte(strings.TrimSuffix(toc.String(), "\n")), 1)
+}
+
+// headings returns the markdown headings in text that are not inside code blocks, with their
+// Github anchor slugs.
+func headings(text []byte) []heading {
+	var (
+		hs    []heading
+		code  bool
+		slugs = make(map[string]int)
+	)
+	s := bufio.NewScanner(bytes.NewReader(text))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "```") {
+			code = !code
+			continue
+		}
+		m := headingRx.FindStringSubmatch(line)
+		if code || m == nil {
+			continue
+		}
+		h := heading{level: len(m[1]), text: linkRx.ReplaceAllString(m[2], "$1")}
+		h.slug = slug(h.text)
+		if n := slugs[h.slug]; n > 0 {
+			slugs[h.slug]++
+			h.slug = fmt.Sprintf("%s-%d", h.slug, n)
+		} else {
+			slugs[h.slug] = 1
+		}
+		hs = append(hs, h)
+	}
+	return hs
+}
+
+// slug returns the Github anchor of a heading: lower case, without punctuation and with spaces
+// replaced by hyphens.
+func slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}